@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/simonkienzler/crusado/cmd/iteration"
 	"github.com/simonkienzler/crusado/cmd/template"
 	"github.com/simonkienzler/crusado/cmd/version"
 
@@ -21,6 +22,7 @@ current iteration. You can even use Markdown syntax in your descriptions.`,
 func init() {
 	crusadoCmd.AddCommand(version.RootCmd)
 	crusadoCmd.AddCommand(template.RootCmd)
+	crusadoCmd.AddCommand(iteration.RootCmd)
 }
 
 func Execute() error {
@@ -0,0 +1,59 @@
+package iteration
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/simonkienzler/crusado/pkg/workitems"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	CreateCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create the next iterations",
+		Long: `Allows you to create iterations following the last iteration of your team. Start date,
+length and name of the new iterations follow the cadence of the existing ones. The new
+iterations are added to the iteration settings of your team.`,
+		Args: cobra.NoArgs,
+		Run:  Create,
+	}
+)
+
+var (
+	countFlag int
+)
+
+func init() {
+	CreateCmd.PersistentFlags().IntVarP(&countFlag, "count", "c", 1, "number of iterations to create")
+}
+
+func Create(_ *cobra.Command, _ []string) {
+	// TODO implement proper contexts
+	err := CreateNext(context.Background(), countFlag)
+	if err != nil {
+		log.Fatalf("Could not create iterations:\n%v", err)
+	}
+}
+
+func CreateNext(ctx context.Context, count int) error {
+	c, err := newClients(ctx)
+	if err != nil {
+		return err
+	}
+
+	created, err := workitems.CreateNextIterations(ctx, c.workitem, c.work, c.project, c.team, count)
+
+	for i := range created {
+		path := ""
+		if created[i].Path != nil {
+			path = *created[i].Path
+		}
+
+		fmt.Printf("🔁 Iteration %s created successfully\n", path)
+	}
+
+	return err
+}
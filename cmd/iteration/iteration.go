@@ -0,0 +1,79 @@
+package iteration
+
+import (
+	"context"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/simonkienzler/crusado/pkg/config"
+	"github.com/simonkienzler/crusado/pkg/workitems"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/klo"
+)
+
+var (
+	RootCmd = &cobra.Command{
+		Use:     "iteration",
+		Aliases: []string{"i"},
+		Short:   "Work with the iterations of your team",
+		Long:    `Use the iteration subcommands to list and create the iterations templates can be applied in.`,
+		Args:    cobra.NoArgs,
+		Run:     nil,
+	}
+)
+
+var (
+	outputFlag string
+	teamFlag   string
+)
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&teamFlag, "team", "", "team whose iterations are used. Overrides the configured team")
+
+	RootCmd.AddCommand(ListCmd)
+	RootCmd.AddCommand(CreateCmd)
+}
+
+// clients holds everything needed to work with the iterations of a team.
+type clients struct {
+	work     work.Client
+	workitem workitemtracking.Client
+	project  string
+	team     string
+}
+
+// newClients connects to the configured organization, taking the --team flag
+// into account.
+func newClients(ctx context.Context) (*clients, error) {
+	cfg := config.GetConfigOrDie()
+
+	// create a connection to the organization
+	connection := azuredevops.NewPatConnection(cfg.OrganizationURL, cfg.PersonalAccessToken)
+
+	workClient, err := work.NewClient(ctx, connection)
+	if err != nil {
+		return nil, err
+	}
+
+	workitemClient, err := workitemtracking.NewClient(ctx, connection)
+	if err != nil {
+		return nil, err
+	}
+
+	teamName := cfg.TeamName
+	if teamFlag != "" {
+		teamName = teamFlag
+	}
+
+	return &clients{
+		work:     workClient,
+		workitem: workitemClient,
+		project:  cfg.ProjectName,
+		team:     teamName,
+	}, nil
+}
+
+func getPrinter(outputFormat string) (klo.ValuePrinter, error) {
+	return klo.PrinterFromFlag(outputFormat, &workitems.IterationPrinterSpecs)
+}
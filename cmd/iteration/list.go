@@ -0,0 +1,52 @@
+package iteration
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/simonkienzler/crusado/pkg/workitems"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List iterations",
+		Long: `Allows you to list all iterations of your team, including the offset you can pass to
+'crusado template apply --iteration-offset' to target them. You can specify an output format.`,
+		Args: cobra.NoArgs,
+		Run:  List,
+	}
+)
+
+func init() {
+	ListCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "", "define the output format: [wide, yaml, json, jsonpath]")
+}
+
+func List(_ *cobra.Command, _ []string) {
+	// TODO implement proper contexts
+	err := GetAll(context.Background(), outputFlag)
+	if err != nil {
+		log.Fatalf("Could not get iterations:\n%v", err)
+	}
+}
+
+func GetAll(ctx context.Context, outputFormat string) error {
+	c, err := newClients(ctx)
+	if err != nil {
+		return err
+	}
+
+	iterations, err := workitems.GetIterations(ctx, c.work, c.project, c.team)
+	if err != nil {
+		return err
+	}
+
+	printer, err := getPrinter(outputFormat)
+	if err != nil {
+		return err
+	}
+	return printer.Fprint(os.Stdout, iterations)
+}
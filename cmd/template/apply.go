@@ -3,15 +3,19 @@ package template
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"github.com/simonkienzler/crusado/pkg/config"
 	"github.com/simonkienzler/crusado/pkg/crusado"
+	"github.com/simonkienzler/crusado/pkg/journal"
 	"github.com/simonkienzler/crusado/pkg/workitems"
 
 	"github.com/fatih/color"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +26,7 @@ var (
 		Long: `Allows you to create a user story or bug from the template specified by the argument
 given to the command. Supports dry-run, skipping confirmation and let's you specify
 the exact iteration in which to apply the template.`,
-		Args: cobra.ExactArgs(1),
+		Args: applyArgs,
 		Run:  Apply,
 	}
 )
@@ -31,6 +35,22 @@ var (
 	dryRunFlag          bool
 	iterationOffsetFlag int
 	autoApproveFlag     bool
+	setFlag             []string
+	valuesFlag          string
+	fieldFlag           []string
+	tagFlag             []string
+	assignToFlag        string
+	areaPathFlag        string
+	teamFlag            string
+	iterationFlag       string
+	iterationDateFlag   string
+	parentFlag          int
+
+	createMissingIterationFlag bool
+	noRollbackFlag             bool
+	rollbackModeFlag           string
+	resumeFlag                 string
+	batchFlag                  bool
 )
 
 func init() {
@@ -41,116 +61,599 @@ func init() {
 
 	iterationOffsetDesc := "iteration to apply the template in, relative to the current iteration.\n1 will traget the next iteration, -1 the previous one."
 	ApplyCmd.PersistentFlags().IntVarP(&iterationOffsetFlag, "iteration-offset", "i", 1, iterationOffsetDesc)
+
+	iterationDesc := "name or full path of the iteration to apply the template in, given as --iteration=<name>.\n" +
+		"If given without a value, you can pick the iteration from a list"
+	ApplyCmd.PersistentFlags().StringVar(&iterationFlag, "iteration", "", iterationDesc)
+	ApplyCmd.PersistentFlags().Lookup("iteration").NoOptDefVal = pickIterationFlagValue
+
+	iterationDateDesc := "apply the template in the iteration that contains this day, formatted as YYYY-MM-DD"
+	ApplyCmd.PersistentFlags().StringVar(&iterationDateFlag, "iteration-date", "", iterationDateDesc)
+
+	createMissingIterationDesc := "create iterations following the cadence of the existing ones if --iteration-offset\npoints to an iteration that doesn't exist yet"
+	ApplyCmd.PersistentFlags().BoolVar(&createMissingIterationFlag, "create-missing-iteration", false, createMissingIterationDesc)
+
+	ApplyCmd.MarkFlagsMutuallyExclusive("iteration-offset", "iteration", "iteration-date")
+
+	setDesc := "set a template parameter, can be given multiple times: --set key=value"
+	ApplyCmd.PersistentFlags().StringArrayVar(&setFlag, "set", []string{}, setDesc)
+
+	valuesDesc := "YAML file containing template parameter values. Values given with --set take precedence"
+	ApplyCmd.PersistentFlags().StringVar(&valuesFlag, "values", "", valuesDesc)
+
+	fieldDesc := "set a field of the top-level work item, can be given multiple times: --field Microsoft.VSTS.Common.Priority=1"
+	ApplyCmd.PersistentFlags().StringArrayVar(&fieldFlag, "field", []string{}, fieldDesc)
+
+	tagDesc := "add a tag to all created work items, can be given multiple times"
+	ApplyCmd.PersistentFlags().StringArrayVar(&tagFlag, "tag", []string{}, tagDesc)
+
+	assignToDesc := "email address or display name of the user to assign the work items to. Use @me for yourself.\nTasks with an assignee in the template keep it."
+	ApplyCmd.PersistentFlags().StringVar(&assignToFlag, "assign-to", "", assignToDesc)
+
+	areaPathDesc := "area path to create the work items in, absolute or relative to the project.\n" +
+		"Use @team for the team's default area path. Overrides the area path of the template and the config"
+	ApplyCmd.PersistentFlags().StringVar(&areaPathFlag, "area-path", "", areaPathDesc)
+
+	parentDesc := "ID of an existing work item, e.g. a Feature, to create the work item underneath.\nOverrides the parent of the template"
+	ApplyCmd.PersistentFlags().IntVar(&parentFlag, "parent", 0, parentDesc)
+
+	noRollbackDesc := "keep the work items that were already created if applying the template fails"
+	ApplyCmd.PersistentFlags().BoolVar(&noRollbackFlag, "no-rollback", false, noRollbackDesc)
+
+	rollbackModeDesc := fmt.Sprintf("what to do with the work items that were already created if applying the template fails,\n"+
+		"one of %v. Overrides the configured rollback mode", workitems.RollbackModes)
+	ApplyCmd.PersistentFlags().StringVar(&rollbackModeFlag, "rollback-mode", "", rollbackModeDesc)
+
+	ApplyCmd.MarkFlagsMutuallyExclusive("no-rollback", "rollback-mode")
+
+	teamDesc := "team whose iterations and default area path are used. Overrides the configured team,\nthe project's default team is used if neither is set"
+	ApplyCmd.PersistentFlags().StringVar(&teamFlag, "team", "", teamDesc)
+
+	batchDesc := "create the work items with as few batch requests as possible instead of one request per work item.\n" +
+		"Falls back to one request per work item if batch requests are unavailable"
+	ApplyCmd.PersistentFlags().BoolVar(&batchFlag, "batch", false, batchDesc)
+
+	resumeDesc := "ID of a failed run to resume. Creates the work items the run didn't create, using the\n" +
+		"template, parameters, paths and parent of the run, which can't be changed by flags"
+	ApplyCmd.PersistentFlags().StringVar(&resumeFlag, "resume", "", resumeDesc)
+
+	// a run is resumed exactly like it was started
+	for _, flag := range resumeExclusiveFlags {
+		ApplyCmd.MarkFlagsMutuallyExclusive("resume", flag)
+	}
+}
+
+// resumeExclusiveFlags are the flags that can't be used with --resume, as the
+// journal of the run determines what they control.
+var resumeExclusiveFlags = []string{
+	"set", "values", "field", "tag", "assign-to", "team", "area-path", "parent",
+	"iteration-offset", "iteration", "iteration-date", "create-missing-iteration",
+}
+
+// applyArgs requires the template name, unless a run is resumed, as the run
+// knows its template.
+func applyArgs(cmd *cobra.Command, args []string) error {
+	// as --iteration can be given without a value, a value separated by a
+	// space ends up in the arguments
+	if iterationFlag == pickIterationFlagValue && len(args) > 1 {
+		return fmt.Errorf("%w: use --iteration=<name> to apply the template in a specific iteration", errIterationValueSeparated)
+	}
+
+	if resumeFlag != "" {
+		return cobra.MaximumNArgs(1)(cmd, args)
+	}
+
+	return cobra.ExactArgs(1)(cmd, args)
 }
 
 func Apply(_ *cobra.Command, args []string) {
 	// TODO implement proper contexts
 	ctx := context.Background()
 
+	var run *journal.Journal
+	var values map[string]string
+	var err error
+
+	if resumeFlag != "" {
+		run, err = journalStore().Load(resumeFlag)
+		if err != nil {
+			log.Fatalf("Could not get run to resume: %s", err)
+		}
+
+		if run.Status == journal.CompletedStatus {
+			log.Fatalf("Could not resume run %s: %s", run.RunID, journal.ErrRunCompleted)
+		}
+
+		if len(args) == 1 && args[0] != run.Template {
+			log.Fatalf("Could not resume run %s: it applied template '%s', not '%s'", run.RunID, run.Template, args[0])
+		}
+
+		// the run is continued exactly like it was started, the flags that
+		// would change it can't be used together with --resume
+		values = run.Parameters
+		fieldFlag = run.Fields
+		tagFlag = run.Tags
+		assignToFlag = run.AssignTo
+		teamFlag = run.Team
+		areaPathFlag = run.AreaPath
+		iterationFlag = run.IterationPath
+		parentFlag = run.Parent
+	} else {
+		values, err = parameterValues(setFlag, valuesFlag)
+		if err != nil {
+			log.Fatalf("Could not get parameter values: %s", err)
+		}
+
+		run = journal.New(args[0])
+	}
+
 	wiService, err := workitemsService(ctx, dryRunFlag)
 	if err != nil {
 		log.Fatalf("Error during service creation: %s", err)
 	}
 
-	ApplyFlow(ctx, crusadoService(), wiService, args[0])
+	ApplyFlow(ctx, crusadoService(), wiService, run.Template, values, run)
 }
 
-func ApplyFlow(ctx context.Context, tplService *crusado.Service, wiService *workitems.Service, templateName string) {
-	var createdItemHint string
+// ApplyFlow applies the template and records the run in the given journal, so
+// it can be resumed. Work items the journal already contains aren't created
+// again.
+func ApplyFlow(ctx context.Context, tplService *crusado.Service, wiService *workitems.Service, templateName string, values map[string]string, run *journal.Journal) {
+	rollbackMode, err := rollbackMode()
+	if err != nil {
+		log.Fatalf("Could not get rollback mode: %s", err)
+	}
+
+	template := renderTemplate(ctx, tplService, wiService, templateName, values, run)
+	tree := itemTree(ctx, wiService, template, run)
+	parentID, parent := resolveLocation(ctx, wiService, template)
+
+	run.Fields = fieldFlag
+	run.Tags = tagFlag
+	run.AssignTo = assignToFlag
+	run.Team = wiService.TeamName
+	run.AreaPath = wiService.AreaPath
+	run.IterationPath = wiService.IterationPath
+	run.Parent = parentID
+
+	coloredAreaPathPrinter(wiService.AreaPath)
+	coloredIterationPathPrinter(wiService.IterationPath)
 
+	if parent != nil {
+		coloredParentPrinter(parentID, parent)
+	}
+
+	if !autoApproveFlag {
+		if !previewAndConfirm(wiService, tree) {
+			fmt.Printf("No work items created.\n")
+			return
+		}
+
+		fmt.Println()
+	}
+
+	restoredLinks := linkedPredecessors(tree)
+
+	createTree(ctx, wiService, tree, parent, run, rollbackMode)
+
+	printDependencies(tree, restoredLinks)
+}
+
+// linkedPredecessors returns the indexes of the predecessors each item of the
+// tree is already linked to.
+func linkedPredecessors(tree *workitems.Item) map[*workitems.Item]map[int]bool {
+	linked := map[*workitems.Item]map[int]bool{}
+
+	tree.Walk(func(item *workitems.Item, _ int) {
+		for _, predecessor := range item.Linked {
+			if linked[item] == nil {
+				linked[item] = map[int]bool{}
+			}
+			linked[item][predecessor] = true
+		}
+	})
+
+	return linked
+}
+
+// printDependencies prints the dependencies between the items of the tree.
+// Links restored from the journal were created by a previous run and are
+// labeled as such.
+func printDependencies(tree *workitems.Item, restoredLinks map[*workitems.Item]map[int]bool) {
+	linkedHint := "linked successfully"
 	if dryRunFlag {
-		createdItemHint = "would be created"
-	} else {
-		createdItemHint = "created successfully"
+		linkedHint = "would be linked"
 	}
 
+	tree.Walk(func(item *workitems.Item, depth int) {
+		for _, successor := range item.Children {
+			for _, predecessor := range successor.DependsOn {
+				hint := linkedHint
+				if restoredLinks[successor][predecessor] {
+					hint = "already linked by a previous run"
+				}
+
+				coloredDependencyPrinter(depth+1, item.Children[predecessor].Title, successor.Title, hint)
+			}
+		}
+	})
+}
+
+// renderTemplate returns the template with the given name, rendered with the
+// given parameter values and the ones the user is prompted for, which are
+// recorded in the journal. Field overrides and assignees are applied.
+func renderTemplate(ctx context.Context, tplService *crusado.Service, wiService *workitems.Service, templateName string,
+	values map[string]string, run *journal.Journal,
+) *crusado.Template {
+	// sections are only written to fields the mapped work item types have
+	tplService.SectionFields = wiService.SectionFields()
+
 	template, err := tplService.GetByName(templateName)
 	if err != nil {
 		log.Fatalf("Could not get template:\n%v", err)
 	}
 
-	coloredIterationPathPrinter(wiService.IterationPath)
-
+	// only prompt if the user is around to answer, otherwise rendering fails
+	// with a list of missing parameters
 	if !autoApproveFlag {
-		coloredItemPrinter(template.Type, template.Title, "")
+		values = promptForMissingParameters(template.Parameters, values)
+	}
+
+	run.Parameters = values
+
+	template, err = template.Render(values)
+	if err != nil {
+		log.Fatalf("Could not render template '%s':\n%v", templateName, err)
+	}
+
+	fieldOverrides, err := keyValuePairs(fieldFlag)
+	if err != nil {
+		log.Fatalf("Could not get field values: %s", err)
+	}
 
-		for i := range template.Tasks {
-			task := template.Tasks[i]
-			coloredItemPrinter(workitems.TaskType, task.Title, "")
+	template.SetFields(fieldOverrides)
+
+	// resolve all assignees before creating anything, so unknown users don't
+	// make us fail halfway through
+	if err := resolveAssignees(ctx, wiService, template, assignToFlag); err != nil {
+		log.Fatalf("Could not resolve assignees:\n%v", err)
+	}
+
+	return template
+}
+
+// itemTree returns the tree of work items to create for the rendered template,
+// with the work items the run already created restored from its journal.
+func itemTree(ctx context.Context, wiService *workitems.Service, template *crusado.Template, run *journal.Journal) *workitems.Item {
+	// task dependencies are resolved after rendering, as references by title
+	// might contain parameters just like the titles
+	tree, err := workitems.NewItemTree(template)
+	if err != nil {
+		log.Fatalf("Could not resolve task dependencies:\n%v", err)
+	}
+
+	if err := wiService.CheckTypes(tree); err != nil {
+		log.Fatalf("Could not map template types to work item types:\n%v", err)
+	}
+
+	if err := run.Restore(ctx, wiService, tree); err != nil {
+		log.Fatalf("Could not resume run %s:\n%v", run.RunID, err)
+	}
+
+	return tree
+}
+
+// resolveLocation sets the area path of the service, taking the template and
+// the flag into account, and returns the parent work item to create the
+// template underneath, if any.
+func resolveLocation(ctx context.Context, wiService *workitems.Service, template *crusado.Template) (int, *workitemtracking.WorkItem) {
+	var err error
+
+	areaPath := workitems.SelectAreaPath(wiService.AreaPath, template.AreaPath, areaPathFlag)
+
+	wiService.AreaPath, err = wiService.ResolveAreaPath(ctx, areaPath)
+	if err != nil {
+		log.Fatalf("Could not resolve area path: %s", err)
+	}
+
+	parentID := template.Parent
+	if parentFlag != 0 {
+		parentID = parentFlag
+	}
+
+	// make sure the parent exists and accepts the work item before anything
+	// is created
+	var parent *workitemtracking.WorkItem
+	if parentID != 0 {
+		parent, err = wiService.GetParent(ctx, parentID, template.Type)
+		if err != nil {
+			log.Fatalf("Could not get parent work item: %s", err)
 		}
+	}
 
-		if !confirm("Create these work items in the specified iteration path?") {
-			fmt.Printf("No work items created.\n")
+	return parentID, parent
+}
+
+// previewAndConfirm prints the work items of the tree and asks the user whether
+// to create them.
+func previewAndConfirm(wiService *workitems.Service, tree *workitems.Item) bool {
+	tree.Walk(func(item *workitems.Item, depth int) {
+		hint := assigneeHint(item.Fields[crusado.AssignedToField])
+		if item.WorkItem != nil {
+			hint = fmt.Sprintf("(#%d) already created", *item.WorkItem.Id)
+		}
+
+		coloredItemPrinter(depth, item.Type, wiService.WorkItemTypeName(item.Type), item.Title, hint)
+	})
+
+	return confirm("Create these work items in the specified area and iteration path?")
+}
+
+// createTree creates the work items of the tree underneath the parent and
+// records each of them in the journal of the run, if it can be written. If
+// creating fails, the work items are rolled back, unless --no-rollback is set,
+// and crusado exits. Resuming is only suggested if work items of the run are
+// left.
+func createTree(ctx context.Context, wiService *workitems.Service, tree *workitems.Item, parent *workitemtracking.WorkItem,
+	run *journal.Journal, mode workitems.RollbackMode,
+) {
+	createdItemHint := "created successfully"
+	if dryRunFlag {
+		createdItemHint = "would be created"
+	}
+
+	// the journal is only kept if work items are actually created. A journal
+	// that can't be written, e.g. as there's no home directory, only costs
+	// the ability to resume, so the work items are created nonetheless
+	store := journalStore()
+	journaled := !dryRunFlag
+	if journaled {
+		if err := store.Save(run); err != nil {
+			log.Printf("Could not write journal of run %s, the run can't be resumed if it fails: %s", run.RunID, err)
+			journaled = false
+		}
+	}
+
+	saveRun := func() {
+		if !journaled {
 			return
 		}
 
-		fmt.Println()
+		run.Record(tree)
+		if err := store.Save(run); err != nil {
+			log.Printf("Could not write journal of run %s: %s", run.RunID, err)
+		}
+	}
+
+	err := wiService.CreateTree(ctx, tree, parent, func(item *workitems.Item, depth int) {
+		saveRun()
+
+		hint := createdItemHint
+
+		if item.Type != crusado.TaskType {
+			url, err := wiService.GetWorkItemHTMLRef(item.WorkItem)
+			if err == nil && url != nil {
+				hint += fmt.Sprintf(" at %s", *url)
+			}
+		}
+
+		coloredItemPrinter(depth, item.Type, wiService.WorkItemTypeName(item.Type), item.Title, hint)
+	})
+	if err != nil {
+		if dryRunFlag {
+			log.Fatalf("Could not create from template '%s': %s", run.Template, err)
+		}
+
+		fmt.Printf("\nCould not create from template '%s': %s\n", run.Template, err)
+		if !noRollbackFlag {
+			rollback(ctx, wiService, tree, mode)
+		}
+
+		run.Status = journal.FailedStatus
+		saveRun()
+
+		// after a complete rollback, resuming would create everything again
+		if journaled && run.Resumable() {
+			fmt.Printf("Resume the run with: crusado template apply --resume %s\n", run.RunID)
+		}
+		os.Exit(1)
+	}
+
+	run.Status = journal.CompletedStatus
+	saveRun()
+}
+
+// rollbackMode returns the rollback mode given by flag, falling back to the
+// configured one.
+func rollbackMode() (workitems.RollbackMode, error) {
+	if rollbackModeFlag != "" {
+		return workitems.ParseRollbackMode(rollbackModeFlag)
 	}
 
-	userStory, err := wiService.Create(ctx, template.Title, template.Description, template.Type)
+	return workitems.ParseRollbackMode(config.GetConfigOrDie().RollbackMode)
+}
+
+// rollback rolls back all work items of the tree that were created and reports
+// each of them, as well as the ones that couldn't be rolled back.
+func rollback(ctx context.Context, wiService *workitems.Service, tree *workitems.Item, mode workitems.RollbackMode) {
+	rolledBackHint := "deleted"
+	if mode == workitems.RemoveRollback {
+		rolledBackHint = "moved to state " + workitems.RemovedState
+	}
+
+	fmt.Printf("Rolling back the work items created so far:\n")
+
+	count := 0
+	err := wiService.Rollback(ctx, tree, mode, func(item *workitems.Item, depth int) {
+		count++
+		coloredItemPrinter(depth, item.Type, wiService.WorkItemTypeName(item.Type), item.Title, fmt.Sprintf("(#%d) %s", *item.WorkItem.Id, rolledBackHint))
+	})
+
+	fmt.Printf("Rolled back %d work item(s).\n", count)
+
 	if err != nil {
-		log.Fatalf("Could not create from template '%s': %s", templateName, err)
+		log.Printf("Some work items could not be rolled back and have to be cleaned up manually:\n%v", err)
 	}
+}
 
-	createdItemHintWithURL := createdItemHint
+// resolveAssignees replaces the assignees of the template, its tasks and its
+// children with the identities they resolve to. The given assignee overrides
+// the one of the template and is used for all tasks and children that don't
+// have an assignee. Returns the errors of all assignees that couldn't be
+// resolved.
+func resolveAssignees(ctx context.Context, wiService *workitems.Service, template *crusado.Template, assignee string) error {
+	assignees := []*string{}
+
+	var collect func(tasks []crusado.Task, children []crusado.Child)
+	collect = func(tasks []crusado.Task, children []crusado.Child) {
+		for i := range tasks {
+			assignees = append(assignees, &tasks[i].AssignedTo)
+		}
 
-	url, err := wiService.GetWorkItemHTMLRef(userStory)
-	if err == nil && url != nil {
-		createdItemHintWithURL += fmt.Sprintf(" at %s", *url)
+		for i := range children {
+			assignees = append(assignees, &children[i].AssignedTo)
+			collect(children[i].Tasks, children[i].Children)
+		}
 	}
+	collect(template.Tasks, template.Children)
 
-	coloredItemPrinter(template.Type, template.Title, createdItemHintWithURL)
+	if assignee != "" {
+		template.AssignedTo = assignee
 
-	for i := range template.Tasks {
-		task := template.Tasks[i]
+		for _, assignedTo := range assignees {
+			if *assignedTo == "" {
+				*assignedTo = assignee
+			}
+		}
+	}
 
-		_, err = wiService.CreateTaskUnderneath(ctx, task.Title, task.Description, userStory)
+	resolved := map[string]string{}
+	errs := []error{}
+
+	resolve := func(name string) string {
+		if name == "" {
+			return ""
+		}
+
+		if identity, exists := resolved[name]; exists {
+			return identity
+		}
+
+		identity, err := wiService.ResolveIdentity(ctx, name)
 		if err != nil {
-			log.Fatalf("Could not create task: %s", err)
+			errs = append(errs, err)
 		}
+		resolved[name] = identity
+
+		return identity
+	}
+
+	template.AssignedTo = resolve(template.AssignedTo)
+
+	for _, assignedTo := range assignees {
+		*assignedTo = resolve(*assignedTo)
+	}
+
+	return errors.Join(errs...)
+}
 
-		coloredItemPrinter(workitems.TaskType, task.Title, createdItemHint)
+func assigneeHint(assignee string) string {
+	if assignee == "" {
+		return ""
 	}
+
+	return fmt.Sprintf("(assigned to %s)", assignee)
 }
 
-func coloredItemPrinter(templateType crusado.Type, title, addendum string) {
+func coloredItemPrinter(depth int, templateType crusado.Type, itemType, title, addendum string) {
 	const (
-		storyIcon = "📖"
-		bugIcon   = "🐛"
-		taskIcon  = "📋"
+		epicIcon       = "👑"
+		featureIcon    = "🏆"
+		storyIcon      = "📖"
+		bugIcon        = "🐛"
+		issueIcon      = "❗"
+		impedimentIcon = "🚧"
+		testCaseIcon   = "🧪"
+		taskIcon       = "📋"
+		customIcon     = "📄"
 	)
 
-	icon := ""
-	itemType := ""
+	icon := customIcon
 	txtColor := color.FgCyan
 
 	switch templateType {
+	case crusado.EpicType:
+		icon = epicIcon
+		txtColor = color.FgMagenta
+	case crusado.FeatureType:
+		icon = featureIcon
+		txtColor = color.FgBlue
 	case crusado.UserStoryType:
 		icon = storyIcon
 		txtColor = color.FgGreen
-		itemType = workitems.UserStoryType
 	case crusado.BugType:
 		icon = bugIcon
 		txtColor = color.FgRed
-		itemType = workitems.BugType
-	case workitems.TaskType:
-		icon = "   " + taskIcon
-		itemType = workitems.TaskType
+	case crusado.IssueType:
+		icon = issueIcon
+		txtColor = color.FgHiRed
+	case crusado.ImpedimentType:
+		icon = impedimentIcon
+		txtColor = color.FgHiYellow
+	case crusado.TestCaseType:
+		icon = testCaseIcon
+		txtColor = color.FgHiBlue
+	case crusado.TaskType:
+		icon = taskIcon
 	}
 
-	fmt.Print(icon + " " + itemType + " ")
+	fmt.Print(strings.Repeat("   ", depth) + icon + " " + itemType + " ")
 	color.New(txtColor).Print(title)
 	fmt.Printf(" %s\n", addendum)
 }
 
+func coloredDependencyPrinter(depth int, predecessor, successor, addendum string) {
+	const (
+		dependencyIcon = "🔗"
+	)
+
+	fmt.Print(strings.Repeat("   ", depth) + dependencyIcon + " ")
+	color.New(color.FgCyan).Print(successor)
+	fmt.Print(" depends on ")
+	color.New(color.FgCyan).Print(predecessor)
+	fmt.Printf(" %s\n", addendum)
+}
+
 func coloredIterationPathPrinter(iterationPath string) {
 	const (
 		iterationIcon = "🔁"
 	)
 
-	parts := strings.Split(iterationPath, "\\")
-
 	fmt.Print(iterationIcon + " Iteration Path: ")
+	coloredPathPrinter(iterationPath)
+	fmt.Print("\n")
+}
+
+func coloredAreaPathPrinter(areaPath string) {
+	const (
+		areaIcon = "🗂️"
+	)
+
+	fmt.Print(areaIcon + " Area Path:      ")
+	coloredPathPrinter(areaPath)
+}
+
+func coloredParentPrinter(id int, parent *workitemtracking.WorkItem) {
+	const (
+		parentIcon = "⬆️"
+	)
+
+	fmt.Printf("%s Parent:         %s %d ", parentIcon, workitems.WorkItemFieldValue(parent, workitems.WorkItemTypeField), id)
+	color.New(color.FgYellow).Println(workitems.WorkItemFieldValue(parent, workitems.TitleField))
+}
+
+func coloredPathPrinter(path string) {
+	parts := strings.Split(path, "\\")
 
 	for i := range parts {
 		color.New(color.FgYellow).Print(parts[i])
@@ -160,16 +663,18 @@ func coloredIterationPathPrinter(iterationPath string) {
 		}
 	}
 
-	fmt.Print("\n\n")
+	fmt.Print("\n")
 }
 
-func confirm(prompt string) bool {
-	reader := bufio.NewReader(os.Stdin)
+// stdinReader is shared by all prompts, so input that was buffered by one
+// prompt isn't lost for the next one.
+var stdinReader = bufio.NewReader(os.Stdin)
 
+func confirm(prompt string) bool {
 	for {
 		fmt.Printf("\n%s [y/n]: ", prompt)
 
-		response, err := reader.ReadString('\n')
+		response, err := stdinReader.ReadString('\n')
 		if err != nil {
 			log.Fatal(err)
 		}
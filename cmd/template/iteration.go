@@ -0,0 +1,146 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simonkienzler/crusado/pkg/workitems"
+
+	"github.com/fatih/color"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// pickIterationFlagValue is the value of the --iteration flag if it is given
+// without a value. It can't be an iteration name, as names can't contain '*'.
+const pickIterationFlagValue = "*"
+
+var errIterationValueSeparated = errors.New("too many arguments, --iteration takes its value only after an equals sign")
+
+// selectIterationPath returns the path of the iteration chosen via the
+// iteration flags. Without any of these flags, the iteration is selected by the
+// default offset.
+func selectIterationPath(ctx context.Context, client work.Client, project, team string) (string, error) {
+	switch {
+	case iterationFlag == pickIterationFlagValue:
+		return pickIterationPath(ctx, client, project, team)
+	case iterationFlag != "":
+		return workitems.GetIterationPathByName(ctx, client, project, team, iterationFlag)
+	case iterationDateFlag != "":
+		day, err := time.Parse(time.DateOnly, iterationDateFlag)
+		if err != nil {
+			return "", fmt.Errorf("invalid iteration date '%s', expected format YYYY-MM-DD: %w", iterationDateFlag, err)
+		}
+
+		return workitems.GetIterationPathByDate(ctx, client, project, team, day)
+	default:
+		iterationPath, err := workitems.GetIterationPathFromOffset(ctx, client, project, team, iterationOffsetFlag)
+		if !createMissingIterationFlag || !errors.Is(err, workitems.ErrOffsetTooFarInFuture) {
+			return iterationPath, err
+		}
+
+		return createMissingIterations(ctx, client, project, team)
+	}
+}
+
+// createMissingIterations creates iterations one after another until the one
+// the iteration offset points to exists, then returns its path.
+func createMissingIterations(ctx context.Context, client work.Client, project, team string) (string, error) {
+	if dryRunFlag {
+		return "", fmt.Errorf("%w: iterations are not created in dry-run mode", workitems.ErrOffsetTooFarInFuture)
+	}
+
+	witClient, err := workitemtracking.NewClient(ctx, connection())
+	if err != nil {
+		return "", err
+	}
+
+	for created := 0; created < iterationOffsetFlag; created++ {
+		iterations, err := workitems.CreateNextIterations(ctx, witClient, client, project, team, 1)
+		if err != nil {
+			return "", err
+		}
+
+		for i := range iterations {
+			fmt.Printf("🔁 Iteration %s created successfully\n", stringValue(iterations[i].Path))
+		}
+
+		iterationPath, err := workitems.GetIterationPathFromOffset(ctx, client, project, team, iterationOffsetFlag)
+		if !errors.Is(err, workitems.ErrOffsetTooFarInFuture) {
+			return iterationPath, err
+		}
+	}
+
+	return "", fmt.Errorf("%w: %d", workitems.ErrOffsetTooFarInFuture, iterationOffsetFlag)
+}
+
+// pickIterationPath lists all iterations of the team and lets the user pick one
+// by its number.
+func pickIterationPath(ctx context.Context, client work.Client, project, team string) (string, error) {
+	iterations, err := workitems.ListIterations(ctx, client, project, team)
+	if err != nil {
+		return "", err
+	}
+
+	if len(iterations) == 0 {
+		return "", workitems.ErrIterationNotFound
+	}
+
+	currentIndex, currentExists := workitems.CurrentIterationIndex(iterations, time.Now())
+
+	for i := range iterations {
+		line := fmt.Sprintf("%3d) %s", i+1, stringValue(iterations[i].Path))
+
+		if dates := iterationDates(&iterations[i]); dates != "" {
+			line += " (" + dates + ")"
+		}
+
+		if currentExists && i == currentIndex {
+			color.New(color.FgGreen).Println(line + " [current]")
+		} else {
+			fmt.Println(line)
+		}
+	}
+
+	for {
+		fmt.Printf("\nPick an iteration [1-%d]: ", len(iterations))
+
+		response, err := stdinReader.ReadString('\n')
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		number, err := strconv.Atoi(strings.TrimSpace(response))
+		if err != nil || number < 1 || number > len(iterations) {
+			continue
+		}
+
+		fmt.Println()
+
+		return stringValue(iterations[number-1].Path), nil
+	}
+}
+
+// iterationDates returns the start and finish dates of the iteration, or an
+// empty string if the iteration doesn't have dates.
+func iterationDates(iteration *work.TeamSettingsIteration) string {
+	attributes := iteration.Attributes
+	if attributes == nil || attributes.StartDate == nil || attributes.FinishDate == nil {
+		return ""
+	}
+
+	return attributes.StartDate.Time.Format(time.DateOnly) + " - " + attributes.FinishDate.Time.Format(time.DateOnly)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
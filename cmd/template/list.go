@@ -11,25 +11,31 @@ var (
 	ListCmd = &cobra.Command{
 		Use:   "list",
 		Short: "List crusado templates",
-		Long:  `Allows you to list available user story and bug templates. You can specify an output format.`,
-		Args:  cobra.NoArgs,
-		Run:   List,
+		Long: `Allows you to list available user story and bug templates. You can specify an output format
+and restrict the list to a namespace, which is derived from the subdirectory a template lives in.`,
+		Args: cobra.NoArgs,
+		Run:  List,
 	}
 )
 
+var (
+	namespaceFlag string
+)
+
 func init() {
 	ListCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "", "define the output format: [wide, yaml, json, jsonpath]")
+	ListCmd.PersistentFlags().StringVarP(&namespaceFlag, "namespace", "n", "", "only list templates in this namespace (including nested namespaces)")
 }
 
 func List(_ *cobra.Command, _ []string) {
-	err := GetAll(outputFlag)
+	err := GetAll(namespaceFlag, outputFlag)
 	if err != nil {
 		log.Fatalf("Could not get templates:\n%v", err)
 	}
 }
 
-func GetAll(outputFormat string) error {
-	templates, err := crusadoService().GetAll()
+func GetAll(namespace, outputFormat string) error {
+	templates, err := crusadoService().GetAllInNamespace(namespace)
 	if err != nil {
 		return err
 	}
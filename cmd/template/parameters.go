@@ -0,0 +1,159 @@
+package template
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v2"
+)
+
+// parameterValues collects the parameter values for applying a template. Values
+// are read from the YAML file first, if given, and then overridden by the
+// key=value pairs passed via --set.
+func parameterValues(setValues []string, valuesFile string) (map[string]string, error) {
+	values := map[string]string{}
+
+	if valuesFile != "" {
+		content, err := os.ReadFile(valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read values file %s: %w", valuesFile, err)
+		}
+
+		fileValues := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &fileValues); err != nil {
+			return nil, fmt.Errorf("could not parse values file %s: %w", valuesFile, err)
+		}
+
+		for key, value := range fileValues {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	setMap, err := keyValuePairs(setValues)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range setMap {
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// keyValuePairs turns a list of key=value strings into a map. Later pairs
+// override earlier ones with the same key.
+func keyValuePairs(pairs []string) (map[string]string, error) {
+	values := map[string]string{}
+
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid value '%s': expected format key=value", pair)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// promptForMissingParameters asks the user for the value of each parameter that
+// isn't part of the given values yet. Input is validated right away, the user
+// is asked again if the value is invalid. Returns the completed values.
+func promptForMissingParameters(params []crusado.Parameter, values map[string]string) map[string]string {
+	completed := map[string]string{}
+	for key, value := range values {
+		completed[key] = value
+	}
+
+	for i := range params {
+		param := params[i]
+
+		if _, exists := completed[param.Name]; exists {
+			continue
+		}
+
+		completed[param.Name] = promptForParameter(&param)
+	}
+
+	return completed
+}
+
+func promptForParameter(param *crusado.Parameter) string {
+	fmt.Print("\n")
+	color.New(color.FgYellow).Print(param.Name)
+	if param.Description != "" {
+		fmt.Printf(": %s", param.Description)
+	}
+	fmt.Println()
+
+	for {
+		fmt.Printf("%s: ", parameterPromptHint(param))
+
+		response, err := stdinReader.ReadString('\n')
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		response = strings.TrimSpace(response)
+		if response == "" {
+			response = param.Default
+		}
+
+		if response == "" {
+			if !param.Required {
+				return response
+			}
+
+			color.New(color.FgRed).Println("A value is required.")
+			continue
+		}
+
+		if err := param.Validate(response); err != nil {
+			color.New(color.FgRed).Println(err)
+			continue
+		}
+
+		return response
+	}
+}
+
+// parameterPromptHint summarizes the restrictions and the default of the
+// parameter, e.g. "value [dev, prod] (default: dev)".
+func parameterPromptHint(param *crusado.Parameter) string {
+	hint := "value"
+
+	if len(param.AllowedValues) > 0 {
+		hint += fmt.Sprintf(" [%s]", strings.Join(param.AllowedValues, ", "))
+	}
+
+	if param.Pattern != "" {
+		hint += fmt.Sprintf(" matching '%s'", param.Pattern)
+	}
+
+	if param.Min != nil || param.Max != nil {
+		lower, upper := "", ""
+		if param.Min != nil {
+			lower = strconv.Itoa(*param.Min)
+		}
+		if param.Max != nil {
+			upper = strconv.Itoa(*param.Max)
+		}
+		hint += fmt.Sprintf(" in range [%s..%s]", lower, upper)
+	}
+
+	if param.Default != "" {
+		hint += fmt.Sprintf(" (default: %s)", param.Default)
+	} else if !param.Required {
+		hint += " (optional)"
+	}
+
+	return hint
+}
@@ -0,0 +1,36 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyValuePairs(t *testing.T) {
+	tests := []struct {
+		name        string
+		pairs       []string
+		expected    map[string]string
+		expectedErr bool
+	}{
+		{name: "none", pairs: nil, expected: map[string]string{}},
+		{name: "pairs", pairs: []string{"env=dev", "team=backend"}, expected: map[string]string{"env": "dev", "team": "backend"}},
+		{name: "later pairs override", pairs: []string{"env=dev", "env=prod"}, expected: map[string]string{"env": "prod"}},
+		{name: "value containing =", pairs: []string{"query=a=b"}, expected: map[string]string{"query": "a=b"}},
+		{name: "empty value", pairs: []string{"env="}, expected: map[string]string{"env": ""}},
+		{name: "missing key", pairs: []string{"=value"}, expectedErr: true},
+		{name: "missing =", pairs: []string{"env"}, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := keyValuePairs(tt.pairs)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, values)
+			}
+		})
+	}
+}
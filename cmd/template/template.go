@@ -3,12 +3,20 @@ package template
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/core"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/location"
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtrackingprocess"
 	"github.com/simonkienzler/crusado/pkg/config"
 	"github.com/simonkienzler/crusado/pkg/crusado"
+	"github.com/simonkienzler/crusado/pkg/journal"
 	"github.com/simonkienzler/crusado/pkg/workitems"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/klo"
@@ -38,16 +46,30 @@ func init() {
 func crusadoService() *crusado.Service {
 	cfg := config.GetConfigOrDie()
 
+	// template types that are only part of the configured type mapping are
+	// custom types
+	types, err := workitems.ParseTypeMapping(cfg.TypeMapping)
+	if err != nil {
+		log.Fatalf("Could not parse %s: %s", config.TypeMappingEnvVarKey, err)
+	}
+
 	return &crusado.Service{
 		TemplatesDirectory: cfg.TemplatesDirectory,
+		CustomTypes:        types.TemplateTypes(),
 	}
 }
 
-func workitemsService(ctx context.Context, useDryRunMode bool) (*workitems.Service, error) {
+func journalStore() *journal.Store {
 	cfg := config.GetConfigOrDie()
 
-	// create a connection to the organization
-	connection := azuredevops.NewPatConnection(cfg.OrganizationURL, cfg.PersonalAccessToken)
+	return &journal.Store{
+		Directory: cfg.JournalDirectory,
+	}
+}
+
+func workitemsService(ctx context.Context, useDryRunMode bool) (*workitems.Service, error) {
+	cfg := config.GetConfigOrDie()
+	connection := connection()
 
 	workitemClient, err := workitemtracking.NewClient(ctx, connection)
 	if err != nil {
@@ -59,7 +81,22 @@ func workitemsService(ctx context.Context, useDryRunMode bool) (*workitems.Servi
 		return nil, err
 	}
 
-	iterationPath, err := workitems.GetIterationPathFromOffset(ctx, workClient, cfg.ProjectName, iterationOffsetFlag)
+	identityClient, err := identity.NewClient(ctx, connection)
+	if err != nil {
+		return nil, err
+	}
+
+	teamName := cfg.TeamName
+	if teamFlag != "" {
+		teamName = teamFlag
+	}
+
+	iterationPath, err := selectIterationPath(ctx, workClient, cfg.ProjectName, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := typeMapping(ctx, connection, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -67,16 +104,70 @@ func workitemsService(ctx context.Context, useDryRunMode bool) (*workitems.Servi
 	// configure the workitems service
 	workitemsService := workitems.Service{
 		WorkitemClient: workitemClient,
+		WorkClient:     workClient,
+		IdentityClient: identityClient,
+		LocationClient: location.NewClient(ctx, connection),
 		DryRun:         useDryRunMode,
 
 		ProjectName:   cfg.ProjectName,
-		AreaPath:      cfg.ProjectName,
+		TeamName:      teamName,
+		AreaPath:      cfg.AreaPath,
 		IterationPath: iterationPath,
+
+		Tags:  tagFlag,
+		Types: types,
+
+		Batch:      batchFlag,
+		Connection: connection,
 	}
 
 	return &workitemsService, nil
 }
 
+// typeMapping returns the work item types of the configured process, or of the
+// process detected from the project, with the configured type mapping applied.
+func typeMapping(ctx context.Context, connection *azuredevops.Connection, cfg config.Crusado) (workitems.TypeMapping, error) {
+	process := cfg.Process
+
+	if process == "" {
+		coreClient, err := core.NewClient(ctx, connection)
+		if err != nil {
+			return nil, err
+		}
+
+		processClient, err := workitemtrackingprocess.NewClient(ctx, connection)
+		if err != nil {
+			return nil, err
+		}
+
+		process, err = workitems.DetectProcess(ctx, coreClient, processClient, cfg.ProjectName)
+		if err != nil {
+			log.Printf("Could not detect the process of project %s, assuming %s (set %s to one of %v): %s",
+				cfg.ProjectName, workitems.AgileProcess, config.ProcessEnvVarKey, workitems.ProcessNames(), err)
+			process = workitems.AgileProcess
+		}
+	}
+
+	types, err := workitems.TypeMappingForProcess(process)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := workitems.ParseTypeMapping(cfg.TypeMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.With(overrides), nil
+}
+
+// connection creates a connection to the configured organization.
+func connection() *azuredevops.Connection {
+	cfg := config.GetConfigOrDie()
+
+	return azuredevops.NewPatConnection(cfg.OrganizationURL, cfg.PersonalAccessToken)
+}
+
 func getPrinter(outputFormat string) (klo.ValuePrinter, error) {
 	return klo.PrinterFromFlag(outputFormat, &crusado.PrinterSpecs)
 }
@@ -84,11 +175,94 @@ func getPrinter(outputFormat string) (klo.ValuePrinter, error) {
 func prettyPrintTemplate(template *crusado.Template) {
 	// TODO add color
 	fmt.Printf("Name:             %s\n", template.Name)
+	fmt.Printf("Namespace:        %s\n", template.Namespace)
 	fmt.Printf("Type:             %s\n", template.Type)
 	fmt.Printf("Title:            %s\n", template.Title)
+	fmt.Printf("Tags:             %s\n", workitems.JoinTags(template.Tags))
+	if template.Parent != 0 {
+		fmt.Printf("Parent:           %d\n", template.Parent)
+	}
 	fmt.Printf("Number of Tasks:  %d\n", len(template.Tasks))
 	fmt.Print("Task Overview:\n")
 	for _, task := range template.Tasks {
-		fmt.Printf("  - %s\n", task.Title)
+		fmt.Printf("  - %s (%s)\n", task.Title, task.Origin())
+		if len(task.DependsOn) > 0 {
+			fmt.Printf("    depends on: %s\n", strings.Join(task.DependsOn, ", "))
+		}
+	}
+
+	if len(template.Children) > 0 {
+		fmt.Print("Children:\n")
+		printChildren(template.Children, 1)
+	}
+
+	if len(template.Fields) > 0 {
+		fmt.Print("Fields:\n")
+		for _, field := range sortedKeys(template.Fields) {
+			fmt.Printf("  - %s: %s\n", field, template.Fields[field])
+		}
+	}
+
+	if len(template.RichTextFields) > 0 {
+		fmt.Print("Rich Text Fields:\n")
+		for _, field := range sortedKeys(template.RichTextFields) {
+			fmt.Printf("  - %s\n", field)
+		}
+	}
+
+	if len(template.Parameters) == 0 {
+		return
+	}
+
+	fmt.Print("Parameters:\n")
+	for _, param := range template.Parameters {
+		fmt.Printf("  - %s", param.Name)
+		if param.Required {
+			fmt.Print(" (required)")
+		}
+		if param.Default != "" {
+			fmt.Printf(" [default: %s]", param.Default)
+		}
+		if len(param.AllowedValues) > 0 {
+			fmt.Printf(" [allowed: %s]", strings.Join(param.AllowedValues, ", "))
+		}
+		if param.Description != "" {
+			fmt.Printf(": %s", param.Description)
+		}
+		fmt.Println()
 	}
 }
+
+// printChildren prints the children with their tasks and children as a tree,
+// indented by the given level.
+func printChildren(children []crusado.Child, level int) {
+	indent := strings.Repeat("  ", level)
+
+	for i := range children {
+		child := &children[i]
+		fmt.Printf("%s- [%s] %s", indent, child.Type, child.Title)
+		if child.Link == crusado.TestedByLink {
+			fmt.Print(" (tests parent)")
+		}
+		fmt.Println()
+
+		for _, task := range child.Tasks {
+			fmt.Printf("%s  - [%s] %s\n", indent, crusado.TaskType, task.Title)
+			if len(task.DependsOn) > 0 {
+				fmt.Printf("%s      depends on: %s\n", indent, strings.Join(task.DependsOn, ", "))
+			}
+		}
+
+		printChildren(child.Children, level+1)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
 )
 
 const (
@@ -10,6 +11,12 @@ const (
 	AzurePATEnvVarKey        = "CRUSADO_AZURE_PAT"
 	ProjectNameEnvVarKey     = "CRUSADO_AZURE_PROJECT_NAME"
 	TemplatesDirEnvVarKey    = "CRUSADO_TEMPLATES_DIR"
+	AreaPathEnvVarKey        = "CRUSADO_AZURE_AREA_PATH"
+	TeamNameEnvVarKey        = "CRUSADO_AZURE_TEAM"
+	ProcessEnvVarKey         = "CRUSADO_AZURE_PROCESS"
+	TypeMappingEnvVarKey     = "CRUSADO_TYPE_MAPPING"
+	RollbackModeEnvVarKey    = "CRUSADO_ROLLBACK_MODE"
+	JournalDirEnvVarKey      = "CRUSADO_JOURNAL_DIR"
 )
 
 type Crusado struct {
@@ -17,6 +24,29 @@ type Crusado struct {
 	PersonalAccessToken string
 	ProjectName         string
 	TemplatesDirectory  string
+
+	// AreaPath is optional and defaults to the project name
+	AreaPath string
+
+	// TeamName is optional, the project's default team is used if empty
+	TeamName string
+
+	// Process is optional and names the process preset whose work item types
+	// are used. It's detected from the project if empty
+	Process string
+
+	// TypeMapping is optional and maps template types to work item types,
+	// overriding the ones of the process, e.g. "UserStory=Requirement"
+	TypeMapping string
+
+	// RollbackMode is optional and determines whether work items created by a
+	// failed apply are deleted or moved to the Removed state. Defaults to
+	// deleting them
+	RollbackMode string
+
+	// JournalDirectory is optional and holds the journals of all applies, so
+	// failed ones can be resumed. Defaults to ~/.crusado/journal
+	JournalDirectory string
 }
 
 func GetConfigOrDie() Crusado {
@@ -48,6 +78,34 @@ func GetConfigOrDie() Crusado {
 		log.Printf("Required environment variable %s is not set", TemplatesDirEnvVarKey)
 	}
 
+	if areaPath, exists := os.LookupEnv(AreaPathEnvVarKey); exists {
+		cfg.AreaPath = areaPath
+	} else {
+		cfg.AreaPath = cfg.ProjectName
+	}
+
+	if teamName, exists := os.LookupEnv(TeamNameEnvVarKey); exists {
+		cfg.TeamName = teamName
+	}
+
+	if process, exists := os.LookupEnv(ProcessEnvVarKey); exists {
+		cfg.Process = process
+	}
+
+	if typeMapping, exists := os.LookupEnv(TypeMappingEnvVarKey); exists {
+		cfg.TypeMapping = typeMapping
+	}
+
+	if rollbackMode, exists := os.LookupEnv(RollbackModeEnvVarKey); exists {
+		cfg.RollbackMode = rollbackMode
+	}
+
+	if journalDirectory, exists := os.LookupEnv(JournalDirEnvVarKey); exists {
+		cfg.JournalDirectory = journalDirectory
+	} else if home, err := os.UserHomeDir(); err == nil {
+		cfg.JournalDirectory = filepath.Join(home, ".crusado", "journal")
+	}
+
 	// TODO check if TemplatesDirectory is actually a directory
 
 	if incomplete {
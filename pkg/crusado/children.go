@@ -0,0 +1,89 @@
+package crusado
+
+// Child is a work item that is created underneath the work item of a template
+// or of another child. Like the template itself, it can have tasks and
+// children of its own, so a single template can describe a whole tree of work
+// items, e.g. a Feature with multiple User Stories.
+type Child struct {
+	// Type identifies the child as one of AvailableTypes or a custom type. It
+	// has to be lower in the hierarchy than the type of its parent, see
+	// HierarchyLevels
+	Type Type `yaml:"type" json:"type"`
+
+	// Title is the resulting title of the work item in Azure DevOps
+	Title string `yaml:"title" json:"title"`
+
+	// Description is the markdown content of the work item. It is converted to
+	// HTML when the template is applied
+	Description string `yaml:"description" json:"description"`
+
+	// Fields maps reference names of work item fields to their values
+	Fields map[string]string `yaml:"fields" json:"fields,omitempty"`
+
+	// Tags are added to the resulting work item
+	Tags []string `yaml:"tags" json:"tags,omitempty"`
+
+	// AssignedTo is the email address or display name of the user the
+	// resulting work item is assigned to. Use @me for the user of the PAT
+	AssignedTo string `yaml:"assignedTo" json:"assignedTo,omitempty"`
+
+	// Link is the way the child is linked to its parent, one of
+	// AvailableChildLinks. Defaults to ChildLink
+	Link string `yaml:"link" json:"link,omitempty"`
+
+	// RichTextFields maps field reference names to the content of the
+	// markdown sections of the description that are mapped to these fields,
+	// see DefaultSectionFields. Only set once the template is rendered
+	RichTextFields map[string]string `yaml:"-" json:"richTextFields,omitempty"`
+
+	// Tasks are created as children of the resulting work item
+	Tasks []Task `yaml:"tasks" json:"tasks,omitempty"`
+
+	// Children are created underneath the resulting work item
+	Children []Child `yaml:"children" json:"children,omitempty"`
+}
+
+const (
+	// ChildLink links a child to its parent with a parent-child link
+	ChildLink = "child"
+
+	// TestedByLink links a child, usually a test case, to its parent with a
+	// tests/tested by link
+	TestedByLink = "testedBy"
+)
+
+var AvailableChildLinks = []string{
+	ChildLink,
+	TestedByLink,
+}
+
+// HierarchyLevels ranks the types in the backlog hierarchy. Children have to be
+// on a lower level than their parent, e.g. a Feature can have User Stories as
+// children, but not Epics. Types that aren't ranked, like Test Cases, can be
+// children of any type.
+var HierarchyLevels = map[Type]int{
+	EpicType:      3,
+	FeatureType:   2,
+	UserStoryType: 1,
+	BugType:       1,
+}
+
+// WorkItemFields returns all fields of the resulting work item besides title
+// and description. Fields set explicitly take precedence over rich text fields.
+func (c *Child) WorkItemFields() map[string]string {
+	fields := map[string]string{}
+
+	for ref, value := range c.RichTextFields {
+		fields[ref] = value
+	}
+
+	for ref, value := range c.Fields {
+		fields[ref] = value
+	}
+
+	if c.AssignedTo != "" {
+		fields[AssignedToField] = c.AssignedTo
+	}
+
+	return fields
+}
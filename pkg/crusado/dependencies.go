@@ -0,0 +1,69 @@
+package crusado
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TaskDependencies returns the indexes of the tasks each task of the template
+// depends on, in the order of the template's tasks. Dependencies are referenced
+// by ID first, then by title. Returns an error listing all references that
+// don't point to exactly one task.
+func (t *Template) TaskDependencies() ([][]int, error) {
+	return taskDependencies(t.Tasks, fmt.Sprintf("template '%s'", t.Name))
+}
+
+// TaskDependencies returns the indexes of the tasks each task of the child
+// depends on, see Template.TaskDependencies.
+func (c *Child) TaskDependencies() ([][]int, error) {
+	return taskDependencies(c.Tasks, fmt.Sprintf("child '%s'", c.Title))
+}
+
+// taskDependencies resolves the dependencies between the given tasks. The
+// owner of the tasks is used in error messages.
+func taskDependencies(tasks []Task, owner string) ([][]int, error) {
+	dependencies := make([][]int, len(tasks))
+	errs := []error{}
+
+	for i := range tasks {
+		for _, ref := range tasks[i].DependsOn {
+			index, err := findTask(tasks, ref)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%w: '%s' depends on '%s' in %s", err, tasks[i].Title, ref, owner))
+				continue
+			}
+
+			dependencies[i] = append(dependencies[i], index)
+		}
+	}
+
+	return dependencies, errors.Join(errs...)
+}
+
+// findTask returns the index of the task with the given ID or, if no task has
+// that ID, the given title.
+func findTask(tasks []Task, ref string) (int, error) {
+	for i := range tasks {
+		if tasks[i].ID != "" && tasks[i].ID == ref {
+			return i, nil
+		}
+	}
+
+	index := -1
+	for i := range tasks {
+		if tasks[i].Title != ref {
+			continue
+		}
+
+		if index != -1 {
+			return -1, ErrAmbiguousDependency
+		}
+		index = i
+	}
+
+	if index == -1 {
+		return -1, ErrUnknownDependency
+	}
+
+	return index, nil
+}
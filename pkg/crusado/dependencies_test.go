@@ -0,0 +1,82 @@
+package crusado
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTaskDependencies(t *testing.T) {
+	tests := []struct {
+		name         string
+		tasks        []Task
+		expected     [][]int
+		expectedErrs []error
+	}{
+		{
+			name: "no dependencies",
+			tasks: []Task{
+				{Title: "Build"},
+				{Title: "Deploy"},
+			},
+			expected: [][]int{nil, nil},
+		},
+		{
+			name: "by title",
+			tasks: []Task{
+				{Title: "Build"},
+				{Title: "Test", DependsOn: []string{"Build"}},
+				{Title: "Deploy", DependsOn: []string{"Build", "Test"}},
+			},
+			expected: [][]int{nil, {0}, {0, 1}},
+		},
+		{
+			name: "ID before title",
+			tasks: []Task{
+				{Title: "build"},
+				{Title: "Build", ID: "build"},
+				{Title: "Deploy", DependsOn: []string{"build"}},
+			},
+			expected: [][]int{nil, nil, {1}},
+		},
+		{
+			name: "ambiguous title",
+			tasks: []Task{
+				{Title: "Build"},
+				{Title: "Build"},
+				{Title: "Deploy", DependsOn: []string{"Build"}},
+			},
+			expected:     [][]int{nil, nil, nil},
+			expectedErrs: []error{ErrAmbiguousDependency},
+		},
+		{
+			name: "all unresolvable references",
+			tasks: []Task{
+				{Title: "Build", DependsOn: []string{"Checkout"}},
+				{Title: "Deploy", DependsOn: []string{"Build", "Approve"}},
+			},
+			expected:     [][]int{nil, {0}},
+			expectedErrs: []error{ErrUnknownDependency},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependencies, err := taskDependencies(tt.tasks, "template 'test'")
+
+			if len(tt.expectedErrs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expectedErr := range tt.expectedErrs {
+				if !errors.Is(err, expectedErr) {
+					t.Errorf("expected error %v, got %v", expectedErr, err)
+				}
+			}
+
+			if !reflect.DeepEqual(dependencies, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, dependencies)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package crusado
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FragmentsDirectory is the subdirectory of the templates directory that holds
+// fragments. Fragments are not templates themselves, but can be included by
+// templates.
+const FragmentsDirectory = "_fragments"
+
+var (
+	ErrUnknownFragment   = errors.New("template includes a fragment that doesn't exist")
+	ErrDuplicateFragment = errors.New("fragment name is used by more than one file")
+	ErrWrongFragmentKind = errors.New("template includes a fragment of the wrong kind")
+)
+
+// includeAction is the action templates use to include a markdown fragment in
+// their description, e.g. {{ include "dod-footer" }}.
+var includeAction = regexp.MustCompile(`\{\{-?\s*include\s+"([^"]+)"\s*-?\}\}`)
+
+// Fragment is a reusable piece of a template. Markdown fragments provide
+// content for descriptions, YAML fragments provide a list of tasks.
+type Fragment struct {
+	// Markdown is the content of a markdown fragment
+	Markdown string `yaml:"-"`
+
+	// Tasks are the tasks of a YAML fragment
+	Tasks []Task `yaml:"tasks"`
+
+	fileType FileType
+	filePath string
+}
+
+// loadFragments reads all fragments from the fragments directory below the
+// given templates directory. Fragments are named after their path relative to
+// the fragments directory without the file extension, e.g. backend/dod. As the
+// extension is dropped, two files that only differ in it are rejected.
+func loadFragments(templatesDirectory string) (map[string]Fragment, error) {
+	fragments := map[string]Fragment{}
+	fragmentsDirectory := filepath.Join(templatesDirectory, FragmentsDirectory)
+
+	if _, err := os.Stat(fragmentsDirectory); errors.Is(err, fs.ErrNotExist) {
+		return fragments, nil
+	}
+
+	err := filepath.WalkDir(fragmentsDirectory, func(filePath string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+
+		supported, fileType := hasSupportedFileType(e.Name())
+		if !supported {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(fragmentsDirectory, filePath)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("could not read %s: %q", filePath, err)
+		}
+
+		fragment := Fragment{fileType: fileType, filePath: filePath}
+
+		switch fileType {
+		case MarkdownFileType:
+			fragment.Markdown = string(content)
+		case YAMLFileType:
+			if err := yaml.Unmarshal(content, &fragment); err != nil {
+				return fmt.Errorf("could not parse %s: %q", filePath, err)
+			}
+		}
+
+		name := strings.TrimSuffix(filepath.ToSlash(relativePath), filepath.Ext(relativePath))
+		if existing, exists := fragments[name]; exists {
+			return fmt.Errorf("%w: '%s' is defined by %s and %s", ErrDuplicateFragment, name, existing.filePath, filePath)
+		}
+		fragments[name] = fragment
+
+		return nil
+	})
+
+	return fragments, err
+}
+
+// resolveFragments replaces all includes in the tasks and descriptions of the
+// given templates with the content of the referenced fragments.
+func resolveFragments(templateList []Template, fragments map[string]Fragment) error {
+	errs := []error{}
+
+	for i := range templateList {
+		errs = append(errs, templateList[i].includeFragments(fragments))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t *Template) includeFragments(fragments map[string]Fragment) error {
+	errs := []error{}
+
+	includeTasks := func(tasks []Task) []Task {
+		included, err := t.includeTaskFragments(tasks, fragments)
+		errs = append(errs, err)
+		return included
+	}
+
+	t.Tasks = includeTasks(t.Tasks)
+
+	var includeChildTasks func(children []Child)
+	includeChildTasks = func(children []Child) {
+		for i := range children {
+			children[i].Tasks = includeTasks(children[i].Tasks)
+			includeChildTasks(children[i].Children)
+		}
+	}
+	includeChildTasks(t.Children)
+
+	fragmentMarkdown := func(action string) (string, bool) {
+		name := includeAction.FindStringSubmatch(action)[1]
+
+		fragment, exists := fragments[name]
+		if !exists {
+			errs = append(errs, fmt.Errorf("%w: '%s' includes '%s'", ErrUnknownFragment, t.Name, name))
+			return "", false
+		}
+
+		if fragment.fileType != MarkdownFileType {
+			errs = append(errs, fmt.Errorf("%w: '%s' includes YAML fragment '%s' in its description", ErrWrongFragmentKind, t.Name, name))
+			return "", false
+		}
+
+		return fragment.Markdown, true
+	}
+
+	include := func(action string) string {
+		markdown, ok := fragmentMarkdown(action)
+		if !ok {
+			return action
+		}
+
+		return markdown
+	}
+
+	// the description of YAML templates is HTML already, so fragments need to
+	// be converted before they are included there
+	includeHTML := func(action string) string {
+		markdown, ok := fragmentMarkdown(action)
+		if !ok {
+			return action
+		}
+
+		html, err := convertMarkdown([]byte(markdown))
+		if err != nil {
+			errs = append(errs, err)
+			return action
+		}
+
+		return html
+	}
+
+	if t.body != "" {
+		t.body = includeAction.ReplaceAllStringFunc(t.body, include)
+
+		description, err := convertMarkdown([]byte(t.body))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		t.Description = description
+	} else {
+		t.Description = includeAction.ReplaceAllStringFunc(t.Description, includeHTML)
+	}
+
+	return errors.Join(errs...)
+}
+
+// includeTaskFragments replaces all tasks that include a fragment with the
+// tasks of that fragment.
+func (t *Template) includeTaskFragments(tasks []Task, fragments map[string]Fragment) ([]Task, error) {
+	errs := []error{}
+	included := []Task{}
+
+	for _, task := range tasks {
+		if task.Include == "" {
+			included = append(included, task)
+			continue
+		}
+
+		fragment, exists := fragments[task.Include]
+		if !exists {
+			errs = append(errs, fmt.Errorf("%w: '%s' includes tasks of '%s'", ErrUnknownFragment, t.Name, task.Include))
+			continue
+		}
+
+		if fragment.fileType != YAMLFileType {
+			errs = append(errs, fmt.Errorf("%w: '%s' includes tasks of markdown fragment '%s'", ErrWrongFragmentKind, t.Name, task.Include))
+			continue
+		}
+
+		for _, fragmentTask := range fragment.Tasks {
+			fragmentTask.Source = FragmentTaskSource + " " + task.Include
+			included = append(included, fragmentTask)
+		}
+	}
+
+	return included, errors.Join(errs...)
+}
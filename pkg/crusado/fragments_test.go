@@ -0,0 +1,167 @@
+package crusado
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the given contents to their paths below a new templates
+// directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadFragments(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		FragmentsDirectory + "/footer.md":        "Footer\n",
+		FragmentsDirectory + "/backend/dod.yaml": "tasks:\n- title: Review\n",
+	})
+
+	fragments, err := loadFragments(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if footer := fragments["footer"]; footer.Markdown != "Footer\n" || footer.fileType != MarkdownFileType {
+		t.Errorf("expected markdown fragment 'footer', got %+v", footer)
+	}
+
+	if dod := fragments["backend/dod"]; !reflect.DeepEqual(dod.Tasks, []Task{{Title: "Review"}}) || dod.fileType != YAMLFileType {
+		t.Errorf("expected YAML fragment 'backend/dod', got %+v", dod)
+	}
+}
+
+func TestLoadFragmentsRejectsDuplicateNames(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		FragmentsDirectory + "/dod.md":   "Done\n",
+		FragmentsDirectory + "/dod.yaml": "tasks:\n- title: Review\n",
+	})
+
+	if _, err := loadFragments(dir); !errors.Is(err, ErrDuplicateFragment) {
+		t.Errorf("expected %v, got %v", ErrDuplicateFragment, err)
+	}
+}
+
+func TestIncludeFragments(t *testing.T) {
+	fragments := map[string]Fragment{
+		"footer": {Markdown: "Footer", fileType: MarkdownFileType},
+		"hint":   {Markdown: "A **hint**", fileType: MarkdownFileType},
+		"dod":    {Tasks: []Task{{Title: "Review"}}, fileType: YAMLFileType},
+	}
+
+	tests := []struct {
+		name                string
+		template            Template
+		expectedErr         error
+		expectedTasks       []Task
+		expectedBody        string
+		expectedDescription string
+	}{
+		{
+			name: "markdown in description",
+			template: Template{
+				Meta: Meta{Name: "story"},
+				body: `Intro {{ include "footer" }}`,
+			},
+			expectedBody:        "Intro Footer",
+			expectedDescription: "<p>Intro Footer</p>\n",
+		},
+		{
+			name: "markdown in YAML description",
+			template: Template{
+				Meta:        Meta{Name: "story"},
+				Description: `<p>Intro</p>{{ include "hint" }}`,
+			},
+			expectedDescription: "<p>Intro</p><p>A <strong>hint</strong></p>\n",
+		},
+		{
+			name: "tasks of YAML fragment",
+			template: Template{
+				Meta: Meta{Name: "story", Tasks: []Task{{Title: "Implement"}, {Include: "dod"}}},
+			},
+			expectedTasks: []Task{{Title: "Implement"}, {Title: "Review", Source: FragmentTaskSource + " dod"}},
+		},
+		{
+			name: "unknown fragment",
+			template: Template{
+				Meta: Meta{Name: "story", Tasks: []Task{{Include: "missing"}}},
+			},
+			expectedErr:   ErrUnknownFragment,
+			expectedTasks: []Task{},
+		},
+		{
+			name: "YAML fragment in description",
+			template: Template{
+				Meta: Meta{Name: "story"},
+				body: `{{ include "dod" }}`,
+			},
+			expectedErr:  ErrWrongFragmentKind,
+			expectedBody: `{{ include "dod" }}`,
+		},
+		{
+			name: "tasks of markdown fragment",
+			template: Template{
+				Meta: Meta{Name: "story", Tasks: []Task{{Include: "footer"}}},
+			},
+			expectedErr:   ErrWrongFragmentKind,
+			expectedTasks: []Task{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.template.includeFragments(fragments)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if tt.expectedTasks != nil && !reflect.DeepEqual(tt.template.Tasks, tt.expectedTasks) {
+				t.Errorf("expected tasks %+v, got %+v", tt.expectedTasks, tt.template.Tasks)
+			}
+
+			if tt.template.body != tt.expectedBody {
+				t.Errorf("expected body '%s', got '%s'", tt.expectedBody, tt.template.body)
+			}
+
+			if tt.expectedDescription != "" && tt.template.Description != tt.expectedDescription {
+				t.Errorf("expected description '%s', got '%s'", tt.expectedDescription, tt.template.Description)
+			}
+		})
+	}
+}
+
+func TestGetAllFailsOnFragmentOfWrongKind(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		FragmentsDirectory + "/dod.yaml": "tasks:\n- title: Review\n",
+		"story.md":                       "---\nname: story\ntype: UserStory\ntitle: Story\n---\n\n{{ include \"dod\" }}\n",
+	})
+
+	service := &Service{TemplatesDirectory: dir}
+
+	_, err := service.GetAll()
+	if !errors.Is(err, ErrWrongFragmentKind) {
+		t.Fatalf("expected %v, got %v", ErrWrongFragmentKind, err)
+	}
+
+	if !strings.Contains(err.Error(), "'story'") {
+		t.Errorf("expected error to name the template, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package crusado
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// parentDescriptionAction is the action a child template can use in its
+// description to wrap the description of its parent, e.g.
+//
+//	Some introduction.
+//
+//	{{ template "parent" . }}
+//
+//	Some closing words.
+var parentDescriptionAction = regexp.MustCompile(`\{\{-?\s*template\s+"parent"\s*\.?\s*-?\}\}`)
+
+// findParent returns the index of the template the given template extends.
+// Names are first looked up relative to the namespace of the template, then as
+// fully qualified names. Returns -1 if there is no such template.
+func findParent(templateList []Template, template *Template) int {
+	candidates := []string{path.Join(template.Namespace, template.Extends), template.Extends}
+
+	for _, name := range candidates {
+		for i := range templateList {
+			if templateList[i].Name == name && &templateList[i] != template {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// resolveInheritance merges each template in the list with the chain of
+// templates it extends. The list must have been validated with ValidateExtends
+// beforehand, so there are no unknown parents or cycles.
+func resolveInheritance(templateList []Template) error {
+	resolved := make([]bool, len(templateList))
+
+	var resolve func(i int) error
+	resolve = func(i int) error {
+		if resolved[i] {
+			return nil
+		}
+		resolved[i] = true
+
+		child := &templateList[i]
+		if child.Extends == "" {
+			return nil
+		}
+
+		parentIndex := findParent(templateList, child)
+		if err := resolve(parentIndex); err != nil {
+			return err
+		}
+
+		return child.inherit(&templateList[parentIndex])
+	}
+
+	for i := range templateList {
+		if err := resolve(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inherit merges the fields of the parent into the template. Fields set on the
+// template take precedence. Section mappings, fields and tags are merged. Tasks
+// of the template override tasks of the parent with the same title and are
+// appended otherwise. Children are inherited if the template has none.
+// Parameters are merged by name. The description of the parent is used if the
+// template has none, or wherever the template's description contains
+// {{ template "parent" . }}.
+func (t *Template) inherit(parent *Template) error {
+	if t.Summary == "" {
+		t.Summary = parent.Summary
+	}
+
+	if t.Type == "" {
+		t.Type = parent.Type
+	}
+
+	if t.Title == "" {
+		t.Title = parent.Title
+	}
+
+	if t.AreaPath == "" {
+		t.AreaPath = parent.AreaPath
+	}
+
+	if t.AssignedTo == "" {
+		t.AssignedTo = parent.AssignedTo
+	}
+
+	if t.Parent == 0 {
+		t.Parent = parent.Parent
+	}
+
+	t.Sections = mergeMaps(parent.Sections, t.Sections)
+	t.Fields = mergeMaps(parent.Fields, t.Fields)
+	t.Tags = append(append([]string{}, parent.Tags...), t.Tags...)
+
+	t.Tasks = mergeTasks(parent.Name, parent.Tasks, t.Tasks)
+
+	if len(t.Children) == 0 {
+		t.Children = parent.Children
+	}
+	t.Parameters = mergeParameters(parent.Parameters, t.Parameters)
+
+	return t.inheritDescription(parent)
+}
+
+func (t *Template) inheritDescription(parent *Template) error {
+	// markdown templates without a description might still have a body of
+	// blank lines, e.g. left over from the sections that were removed from it
+	blankBody := strings.TrimSpace(t.body) == ""
+
+	switch {
+	case blankBody && t.Description == "":
+		t.body = parent.body
+		t.Description = parent.Description
+	case !blankBody && parentDescriptionAction.MatchString(t.body):
+		// the HTML of YAML templates wouldn't survive the conversion of the
+		// markdown it is wrapped in
+		if parent.body == "" && parent.Description != "" {
+			return fmt.Errorf("%w: '%s' wraps the description of '%s'", ErrIncompatibleParentDescription, t.Name, parent.Name)
+		}
+
+		t.body = parentDescriptionAction.ReplaceAllLiteralString(t.body, parent.body)
+
+		description, err := convertMarkdown([]byte(t.body))
+		if err != nil {
+			return err
+		}
+		t.Description = description
+	case blankBody && parentDescriptionAction.MatchString(t.Description):
+		parentDescription, err := t.wrappedParentDescription(parent)
+		if err != nil {
+			return err
+		}
+
+		t.Description = parentDescriptionAction.ReplaceAllLiteralString(t.Description, parentDescription)
+	}
+
+	return nil
+}
+
+// wrappedParentDescription returns the description of the parent as HTML, so it
+// can be wrapped in the description of a YAML template. The sections of
+// markdown parents that are mapped to fields of the template are moved to its
+// rich text fields instead.
+func (t *Template) wrappedParentDescription(parent *Template) (string, error) {
+	if parent.body == "" {
+		return parent.Description, nil
+	}
+
+	body, sources := extractSections(parent.body, sectionMapping(t.defaultSections(t.Type), t.Sections))
+	if len(sources) > 0 {
+		var err error
+
+		t.richTextSources = sources
+		if t.RichTextFields, err = convertSections(sources); err != nil {
+			return "", err
+		}
+	}
+
+	return convertMarkdown([]byte(body))
+}
+
+func mergeTasks(parentName string, parentTasks, childTasks []Task) []Task {
+	merged := make([]Task, len(parentTasks))
+	copy(merged, parentTasks)
+
+	// tasks inherited over multiple levels keep the template they come from
+	for i := range merged {
+		if merged[i].InheritedFrom == "" {
+			merged[i].InheritedFrom = parentName
+		}
+	}
+
+	for _, task := range childTasks {
+		overridden := false
+
+		for i := range merged {
+			if merged[i].Title == task.Title {
+				merged[i] = task
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			merged = append(merged, task)
+		}
+	}
+
+	return merged
+}
+
+func mergeParameters(parentParams, childParams []Parameter) []Parameter {
+	merged := make([]Parameter, len(parentParams))
+	copy(merged, parentParams)
+
+	for _, param := range childParams {
+		overridden := false
+
+		for i := range merged {
+			if merged[i].Name == param.Name {
+				merged[i] = param
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			merged = append(merged, param)
+		}
+	}
+
+	return merged
+}
+
+// mergeMaps returns a map with all entries of both maps. Entries of the child
+// override the ones of the parent. Returns the child if the parent is empty.
+func mergeMaps(parent, child map[string]string) map[string]string {
+	if len(parent) == 0 {
+		return child
+	}
+
+	merged := map[string]string{}
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range child {
+		merged[key] = value
+	}
+
+	return merged
+}
@@ -0,0 +1,289 @@
+package crusado
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveInheritance(t *testing.T) {
+	templates := []Template{
+		{
+			Namespace: "team",
+			Meta: Meta{
+				Name:    "team/leaf",
+				Extends: "middle",
+				Tasks:   []Task{{Title: "Deploy", Source: YAMLTaskSource}},
+				Tags:    []string{"leaf"},
+			},
+		},
+		{
+			Meta: Meta{
+				Name:    "middle",
+				Extends: "base",
+				Title:   "Middle",
+				Tasks: []Task{
+					{Title: "Review", Source: YAMLTaskSource, Description: "overridden"},
+					{Title: "Test", Source: FragmentTaskSource + " part of qa"},
+				},
+				Fields: map[string]string{"Priority": "1"},
+				Tags:   []string{"middle"},
+			},
+		},
+		{
+			Meta: Meta{
+				Name:  "base",
+				Type:  UserStoryType,
+				Title: "Base",
+				Tasks: []Task{
+					{Title: "Implement", Source: YAMLTaskSource},
+					{Title: "Review", Source: YAMLTaskSource},
+				},
+				Fields:     map[string]string{"Priority": "2", "Risk": "low"},
+				Tags:       []string{"base"},
+				Parameters: []Parameter{{Name: "component"}},
+			},
+		},
+	}
+
+	if err := resolveInheritance(templates); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	leaf := templates[0]
+
+	if leaf.Type != UserStoryType || leaf.Title != "Middle" {
+		t.Errorf("expected type and title of the closest ancestor, got %s '%s'", leaf.Type, leaf.Title)
+	}
+
+	expectedTasks := []Task{
+		{Title: "Implement", Source: YAMLTaskSource, InheritedFrom: "base"},
+		{Title: "Review", Source: YAMLTaskSource, Description: "overridden", InheritedFrom: "middle"},
+		{Title: "Test", Source: FragmentTaskSource + " part of qa", InheritedFrom: "middle"},
+		{Title: "Deploy", Source: YAMLTaskSource},
+	}
+	if !reflect.DeepEqual(leaf.Tasks, expectedTasks) {
+		t.Errorf("expected tasks %+v, got %+v", expectedTasks, leaf.Tasks)
+	}
+
+	expectedOrigins := []string{"yaml of base", "yaml of middle", "fragment part of qa of middle", "yaml"}
+	for i := range leaf.Tasks {
+		if origin := leaf.Tasks[i].Origin(); origin != expectedOrigins[i] {
+			t.Errorf("expected origin '%s', got '%s'", expectedOrigins[i], origin)
+		}
+	}
+
+	if expected := map[string]string{"Priority": "1", "Risk": "low"}; !reflect.DeepEqual(leaf.Fields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, leaf.Fields)
+	}
+
+	if expected := []string{"base", "middle", "leaf"}; !reflect.DeepEqual(leaf.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, leaf.Tags)
+	}
+
+	if len(leaf.Parameters) != 1 || leaf.Parameters[0].Name != "component" {
+		t.Errorf("expected the parameters of the base template, got %+v", leaf.Parameters)
+	}
+}
+
+func TestFindParent(t *testing.T) {
+	templates := []Template{
+		{Meta: Meta{Name: "base"}},
+		{Namespace: "team", Meta: Meta{Name: "team/base", Extends: "base"}},
+		{Namespace: "team", Meta: Meta{Name: "team/story", Extends: "base"}},
+		{Namespace: "other", Meta: Meta{Name: "other/story", Extends: "team/base"}},
+	}
+
+	tests := []struct {
+		name     string
+		template int
+		expected int
+	}{
+		{name: "same namespace first", template: 2, expected: 1},
+		{name: "same name in the parent namespace", template: 1, expected: 0},
+		{name: "fully qualified name", template: 3, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if parent := findParent(templates, &templates[tt.template]); parent != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, parent)
+			}
+		})
+	}
+}
+
+func TestInheritDescription(t *testing.T) {
+	tests := []struct {
+		name                string
+		parent              Template
+		child               Template
+		expectedBody        string
+		expectedDescription string
+		expectedFields      map[string]string
+		expectedErr         error
+	}{
+		{
+			name:                "no description",
+			parent:              Template{Description: "<p>Parent</p>", body: "Parent"},
+			child:               Template{},
+			expectedBody:        "Parent",
+			expectedDescription: "<p>Parent</p>",
+		},
+		{
+			name:                "blank lines only",
+			parent:              Template{Description: "<p>Parent</p>", body: "Parent"},
+			child:               Template{body: "\n  \n"},
+			expectedBody:        "Parent",
+			expectedDescription: "<p>Parent</p>",
+		},
+		{
+			name:                "own description",
+			parent:              Template{Description: "Parent"},
+			child:               Template{Description: "Child"},
+			expectedDescription: "Child",
+		},
+		{
+			name:                "wrapped markdown",
+			parent:              Template{Description: "<p><em>Parent</em></p>", body: "*Parent*"},
+			child:               Template{body: "Intro\n\n{{ template \"parent\" . }}\n\nOutro"},
+			expectedBody:        "Intro\n\n*Parent*\n\nOutro",
+			expectedDescription: "<p>Intro</p>\n<p><em>Parent</em></p>\n<p>Outro</p>\n",
+		},
+		{
+			name:   "markdown wrapping yaml description",
+			parent: Template{Meta: Meta{Name: "parent"}, Description: "<p>Parent</p>"},
+			child: Template{
+				Meta: Meta{Name: "child"},
+				body: "Intro\n\n{{ template \"parent\" . }}",
+			},
+			expectedBody: "Intro\n\n{{ template \"parent\" . }}",
+			expectedErr:  ErrIncompatibleParentDescription,
+		},
+		{
+			name:   "yaml wrapping markdown description",
+			parent: Template{Description: "<p>Parent <strong>body</strong></p>", body: "Parent **body**\n\n## System Info\n\nOS\n"},
+			child: Template{
+				Meta:        Meta{Type: BugType},
+				Description: "<p>Intro</p>{{ template \"parent\" . }}",
+			},
+			expectedDescription: "<p>Intro</p><p>Parent <strong>body</strong></p>\n",
+			expectedFields:      map[string]string{"Microsoft.VSTS.TCM.SystemInfo": "<p>OS</p>\n"},
+		},
+		{
+			name:                "wrapped yaml description",
+			parent:              Template{Description: "Parent"},
+			child:               Template{Description: "Intro {{ template \"parent\" . }} Outro"},
+			expectedDescription: "Intro Parent Outro",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child := tt.child
+			if err := child.inheritDescription(&tt.parent); !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if !reflect.DeepEqual(child.RichTextFields, tt.expectedFields) {
+				t.Errorf("expected rich text fields %v, got %v", tt.expectedFields, child.RichTextFields)
+			}
+
+			if child.body != tt.expectedBody {
+				t.Errorf("expected body '%s', got '%s'", tt.expectedBody, child.body)
+			}
+
+			if child.Description != tt.expectedDescription {
+				t.Errorf("expected description '%s', got '%s'", tt.expectedDescription, child.Description)
+			}
+		})
+	}
+}
+
+func TestMergeParameters(t *testing.T) {
+	parent := []Parameter{{Name: "component", Required: true}, {Name: "environment", Default: "dev"}}
+	child := []Parameter{{Name: "environment", Default: "prod"}, {Name: "ticket"}}
+
+	expected := []Parameter{{Name: "component", Required: true}, {Name: "environment", Default: "prod"}, {Name: "ticket"}}
+	if merged := mergeParameters(parent, child); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+
+	if parent[1].Default != "dev" {
+		t.Errorf("expected the parameters of the parent to stay unchanged, got %+v", parent)
+	}
+}
+
+func TestMergeMaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		parent   map[string]string
+		child    map[string]string
+		expected map[string]string
+	}{
+		{name: "empty parent", parent: nil, child: map[string]string{"a": "1"}, expected: map[string]string{"a": "1"}},
+		{name: "empty child", parent: map[string]string{"a": "1"}, child: nil, expected: map[string]string{"a": "1"}},
+		{
+			name:     "child overrides parent",
+			parent:   map[string]string{"a": "1", "b": "2"},
+			child:    map[string]string{"b": "3", "c": "4"},
+			expected: map[string]string{"a": "1", "b": "3", "c": "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if merged := mergeMaps(tt.parent, tt.child); !reflect.DeepEqual(merged, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, merged)
+			}
+		})
+	}
+}
+
+func TestMergeTasksKeepsTemplateOfMultiLevelInheritance(t *testing.T) {
+	tasks := mergeTasks("base", []Task{{Title: "Implement", Source: FragmentTaskSource + " definition of done"}}, nil)
+	tasks = mergeTasks("middle", tasks, nil)
+
+	if origin := tasks[0].Origin(); !strings.HasSuffix(origin, "definition of done of base") {
+		t.Errorf("expected the task to be inherited from base, got '%s'", origin)
+	}
+}
+
+func TestGetAllInheritsDescriptionOfBlankBody(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.md":  "---\nname: base\ntype: UserStory\ntitle: Base\n---\nParent\n",
+		"blank.md": "---\nname: blank\nextends: base\n---\n\n",
+		"tasks.md": "---\nname: tasks\nextends: base\n---\n\n## Tasks\n\n### Review\n\nCheck it\n",
+	})
+
+	service := &Service{TemplatesDirectory: dir}
+
+	templates, err := service.GetAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := range templates {
+		if description := templates[i].Description; description != "<p>Parent</p>\n" {
+			t.Errorf("expected '%s' to inherit the description, got '%s'", templates[i].Name, description)
+		}
+	}
+}
+
+func TestGetAllReportsUnknownParentOnce(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"child.md": "---\nname: child\nextends: missing\n---\n\nChild\n",
+	})
+
+	service := &Service{TemplatesDirectory: dir}
+
+	_, err := service.GetAll()
+	if !errors.Is(err, ErrUnknownParent) {
+		t.Fatalf("expected %v, got %v", ErrUnknownParent, err)
+	}
+
+	if count := strings.Count(err.Error(), ErrUnknownParent.Error()); count != 1 {
+		t.Errorf("expected the unknown parent to be reported once, got %d times: %v", count, err)
+	}
+}
@@ -0,0 +1,423 @@
+package crusado
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	ErrMissingRequiredParameters = errors.New("missing values for required parameters")
+	ErrUnknownParameter          = errors.New("value given for parameter that is not declared by the template")
+	ErrValueNotAllowed           = errors.New("value is not allowed for parameter")
+	ErrValueDoesNotMatchPattern  = errors.New("value does not match pattern of parameter")
+	ErrValueNotAnInteger         = errors.New("value is not an integer, which is required for parameter")
+	ErrValueOutOfRange           = errors.New("value is out of range for parameter")
+	ErrRenderingFailed           = errors.New("could not render template")
+)
+
+// Parameter declares a value that has to be supplied when the template is
+// applied. Parameters can be referenced in the title, description and tasks
+// using Go text/template syntax, e.g. {{ .component }}.
+type Parameter struct {
+	// Name is used to reference the parameter within the template
+	Name string `yaml:"name" json:"name"`
+
+	// Description explains the purpose of the parameter to the user
+	Description string `yaml:"description" json:"description,omitempty"`
+
+	// Default is used if no value is supplied for the parameter
+	Default string `yaml:"default" json:"default,omitempty"`
+
+	// Required parameters must have a value, either supplied or by default
+	Required bool `yaml:"required" json:"required,omitempty"`
+
+	// AllowedValues restricts the parameter to a fixed set of values. Any
+	// value is allowed if empty
+	AllowedValues []string `yaml:"allowedValues" json:"allowedValues,omitempty"`
+
+	// Pattern is a regular expression the value has to match
+	Pattern string `yaml:"pattern" json:"pattern,omitempty"`
+
+	// Min is the smallest allowed value. If Min or Max is set, the value has
+	// to be an integer
+	Min *int `yaml:"min" json:"min,omitempty"`
+
+	// Max is the largest allowed value. If Min or Max is set, the value has
+	// to be an integer
+	Max *int `yaml:"max" json:"max,omitempty"`
+}
+
+// Render returns a copy of the template with the title, description, assignee,
+// fields, tags, rich text fields and all tasks and children rendered through
+// Go text/template using the given parameter values. Task and child
+// descriptions are markdown and converted to HTML afterwards, just like the
+// description of markdown templates. Defaults are used for parameters without
+// a value. Returns an error listing all required parameters that don't have a
+// value.
+func (t *Template) Render(values map[string]string) (*Template, error) {
+	data, err := t.ResolveParameters(values)
+	if err != nil {
+		return nil, err
+	}
+
+	// templates without parameters are taken literally, so content like Helm
+	// or Jinja snippets doesn't have to be escaped
+	if len(t.Parameters) == 0 {
+		data = nil
+	}
+
+	rendered := *t
+
+	if rendered.Title, err = renderString("title", t.Title, data); err != nil {
+		return nil, err
+	}
+
+	if rendered.AssignedTo, err = renderString("assignedTo", t.AssignedTo, data); err != nil {
+		return nil, err
+	}
+
+	if rendered.Description, err = t.renderDescription(data); err != nil {
+		return nil, err
+	}
+
+	if len(t.richTextSources) > 0 {
+		if rendered.RichTextFields, err = t.renderRichTextFields(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if rendered.Fields, err = renderFields("fields", t.Fields, data); err != nil {
+		return nil, err
+	}
+
+	if rendered.Tags, err = renderStrings("tags", t.Tags, data); err != nil {
+		return nil, err
+	}
+
+	if rendered.Tasks, err = renderTasks("tasks", t.Tasks, data); err != nil {
+		return nil, err
+	}
+
+	if rendered.Children, err = t.renderChildren("children", t.Children, data); err != nil {
+		return nil, err
+	}
+
+	return &rendered, nil
+}
+
+// renderDescription renders the description. The markdown body of markdown
+// templates is converted to HTML afterwards.
+func (t *Template) renderDescription(data map[string]string) (string, error) {
+	if t.body == "" {
+		return renderString("description", t.Description, data)
+	}
+
+	body, err := renderString("description", t.body, data)
+	if err != nil {
+		return "", err
+	}
+
+	return convertMarkdown([]byte(body))
+}
+
+// renderRichTextFields renders the markdown sections mapped to rich text
+// fields and converts them to HTML, see extractFieldSections.
+func (t *Template) renderRichTextFields(data map[string]string) (map[string]string, error) {
+	sources := map[string]string{}
+	for field, source := range t.richTextSources {
+		var err error
+		if sources[field], err = renderString(field, source, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return convertSections(sources)
+}
+
+// ResolveParameters merges the given values with the defaults of the
+// template's parameters and checks the result against the parameter
+// declarations.
+func (t *Template) ResolveParameters(values map[string]string) (map[string]string, error) {
+	resolved := map[string]string{}
+	declared := map[string]bool{}
+	missing := []string{}
+	errs := []error{}
+
+	for i := range t.Parameters {
+		param := t.Parameters[i]
+		declared[param.Name] = true
+
+		value, supplied := values[param.Name]
+		if !supplied {
+			value = param.Default
+		}
+
+		if value == "" && param.Required {
+			missing = append(missing, param.Name)
+			continue
+		}
+
+		if value != "" {
+			if err := param.Validate(value); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		resolved[param.Name] = value
+	}
+
+	for name := range values {
+		if !declared[name] {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownParameter, name))
+		}
+	}
+
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrMissingRequiredParameters, strings.Join(missing, ", ")))
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// Validate checks the given value against the restrictions of the parameter.
+func (p *Parameter) Validate(value string) error {
+	if err := p.validateAllowedValues(value); err != nil {
+		return err
+	}
+
+	if err := p.validatePattern(value); err != nil {
+		return err
+	}
+
+	return p.validateRange(value)
+}
+
+func (p *Parameter) validateAllowedValues(value string) error {
+	if len(p.AllowedValues) == 0 {
+		return nil
+	}
+
+	for i := range p.AllowedValues {
+		if value == p.AllowedValues[i] {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w '%s': '%s' should be one of %+v", ErrValueNotAllowed, p.Name, value, p.AllowedValues)
+}
+
+func (p *Parameter) validatePattern(value string) error {
+	if p.Pattern == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return err
+	}
+
+	if !pattern.MatchString(value) {
+		return fmt.Errorf("%w '%s': '%s' should match '%s'", ErrValueDoesNotMatchPattern, p.Name, value, p.Pattern)
+	}
+
+	return nil
+}
+
+func (p *Parameter) validateRange(value string) error {
+	if p.Min == nil && p.Max == nil {
+		return nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%w '%s': '%s'", ErrValueNotAnInteger, p.Name, value)
+	}
+
+	if p.Min != nil && number < *p.Min {
+		return fmt.Errorf("%w '%s': %d is smaller than %d", ErrValueOutOfRange, p.Name, number, *p.Min)
+	}
+
+	if p.Max != nil && number > *p.Max {
+		return fmt.Errorf("%w '%s': %d is larger than %d", ErrValueOutOfRange, p.Name, number, *p.Max)
+	}
+
+	return nil
+}
+
+// renderFields renders the values of the given fields into a new map.
+func renderFields(name string, fields map[string]string, data map[string]string) (map[string]string, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	rendered := map[string]string{}
+	for ref, value := range fields {
+		var err error
+		if rendered[ref], err = renderString(name+"."+ref, value, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return rendered, nil
+}
+
+// renderTasks returns rendered copies of the given tasks, with their
+// descriptions converted to HTML.
+func renderTasks(name string, tasks []Task, data map[string]string) ([]Task, error) {
+	if tasks == nil {
+		return nil, nil
+	}
+
+	rendered := make([]Task, len(tasks))
+	copy(rendered, tasks)
+
+	for i := range rendered {
+		var err error
+		task := &rendered[i]
+		prefix := fmt.Sprintf("%s[%d]", name, i)
+
+		if task.Fields, err = renderFields(prefix+".fields", task.Fields, data); err != nil {
+			return nil, err
+		}
+
+		if task.AssignedTo, err = renderString(prefix+".assignedTo", task.AssignedTo, data); err != nil {
+			return nil, err
+		}
+
+		if task.Tags, err = renderStrings(prefix+".tags", task.Tags, data); err != nil {
+			return nil, err
+		}
+
+		if task.Title, err = renderString(prefix+".title", task.Title, data); err != nil {
+			return nil, err
+		}
+
+		// references by title have to be rendered like the titles themselves
+		if task.DependsOn, err = renderStrings(prefix+".dependsOn", task.DependsOn, data); err != nil {
+			return nil, err
+		}
+
+		description, err := renderString(prefix+".description", task.Description, data)
+		if err != nil {
+			return nil, err
+		}
+
+		if task.Description, err = convertTaskMarkdown([]byte(description)); err != nil {
+			return nil, err
+		}
+	}
+
+	return rendered, nil
+}
+
+// renderChildren returns rendered copies of the given children and everything
+// underneath them, with their descriptions converted to HTML.
+func (t *Template) renderChildren(name string, children []Child, data map[string]string) ([]Child, error) {
+	if children == nil {
+		return nil, nil
+	}
+
+	rendered := make([]Child, len(children))
+	copy(rendered, children)
+
+	for i := range rendered {
+		var err error
+		child := &rendered[i]
+		prefix := fmt.Sprintf("%s[%d]", name, i)
+
+		if child.Title, err = renderString(prefix+".title", child.Title, data); err != nil {
+			return nil, err
+		}
+
+		if child.Fields, err = renderFields(prefix+".fields", child.Fields, data); err != nil {
+			return nil, err
+		}
+
+		if child.AssignedTo, err = renderString(prefix+".assignedTo", child.AssignedTo, data); err != nil {
+			return nil, err
+		}
+
+		if child.Tags, err = renderStrings(prefix+".tags", child.Tags, data); err != nil {
+			return nil, err
+		}
+
+		description, err := renderString(prefix+".description", child.Description, data)
+		if err != nil {
+			return nil, err
+		}
+
+		// children only support the default sections of their type
+		description, sources := extractSections(description, sectionMapping(t.defaultSections(child.Type)))
+		if len(sources) > 0 {
+			if child.RichTextFields, err = convertSections(sources); err != nil {
+				return nil, err
+			}
+		}
+
+		if child.Description, err = convertMarkdown([]byte(description)); err != nil {
+			return nil, err
+		}
+
+		if child.Tasks, err = renderTasks(prefix+".tasks", child.Tasks, data); err != nil {
+			return nil, err
+		}
+
+		if child.Children, err = t.renderChildren(prefix+".children", child.Children, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return rendered, nil
+}
+
+// renderStrings renders each of the given strings, e.g. tags or dependencies,
+// into a new slice. Errors name the string by its index.
+func renderStrings(name string, texts []string, data map[string]string) ([]string, error) {
+	if texts == nil {
+		return nil, nil
+	}
+
+	rendered := make([]string, len(texts))
+	for i := range texts {
+		var err error
+		if rendered[i], err = renderString(fmt.Sprintf("%s[%d]", name, i), texts[i], data); err != nil {
+			return nil, err
+		}
+	}
+
+	return rendered, nil
+}
+
+// renderString renders the text with the given parameter values. Texts are
+// returned unchanged if there are no values, see Render.
+func renderString(name, text string, data map[string]string) (string, error) {
+	if data == nil || !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrRenderingFailed, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrRenderingFailed, err)
+	}
+
+	return buf.String(), nil
+}
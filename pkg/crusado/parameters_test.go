@@ -0,0 +1,152 @@
+package crusado
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveParameters(t *testing.T) {
+	one, three := 1, 3
+
+	template := &Template{Meta: Meta{
+		Parameters: []Parameter{
+			{Name: "component", Required: true},
+			{Name: "environment", Default: "dev", AllowedValues: []string{"dev", "prod"}},
+			{Name: "ticket", Pattern: `^[A-Z]+-\d+$`},
+			{Name: "replicas", Min: &one, Max: &three},
+		},
+	}}
+
+	tests := []struct {
+		name         string
+		values       map[string]string
+		expected     map[string]string
+		expectedErrs []error
+	}{
+		{
+			name:   "defaults",
+			values: map[string]string{"component": "api"},
+			expected: map[string]string{
+				"component":   "api",
+				"environment": "dev",
+				"ticket":      "",
+				"replicas":    "",
+			},
+		},
+		{
+			name: "valid values",
+			values: map[string]string{
+				"component":   "api",
+				"environment": "prod",
+				"ticket":      "OPS-42",
+				"replicas":    "3",
+			},
+			expected: map[string]string{
+				"component":   "api",
+				"environment": "prod",
+				"ticket":      "OPS-42",
+				"replicas":    "3",
+			},
+		},
+		{
+			name:         "missing required parameter",
+			values:       map[string]string{},
+			expectedErrs: []error{ErrMissingRequiredParameters},
+		},
+		{
+			name: "all invalid values",
+			values: map[string]string{
+				"component":   "api",
+				"environment": "staging",
+				"ticket":      "42",
+				"replicas":    "4",
+				"owner":       "me",
+			},
+			expectedErrs: []error{ErrValueNotAllowed, ErrValueDoesNotMatchPattern, ErrValueOutOfRange, ErrUnknownParameter},
+		},
+		{
+			name:         "value that isn't an integer",
+			values:       map[string]string{"component": "api", "replicas": "two"},
+			expectedErrs: []error{ErrValueNotAnInteger},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := template.ResolveParameters(tt.values)
+
+			if len(tt.expectedErrs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expectedErr := range tt.expectedErrs {
+				if !errors.Is(err, expectedErr) {
+					t.Errorf("expected error %v, got %v", expectedErr, err)
+				}
+			}
+
+			if !reflect.DeepEqual(resolved, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestRenderWithoutParameters(t *testing.T) {
+	template := &Template{Meta: Meta{
+		Title: "Upgrade {{ .Values.image.tag }}",
+		Tags:  []string{"{{ .Release.Name }}"},
+	}}
+
+	rendered, err := template.Render(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rendered.Title != template.Title {
+		t.Errorf("expected title '%s', got '%s'", template.Title, rendered.Title)
+	}
+
+	if !reflect.DeepEqual(rendered.Tags, template.Tags) {
+		t.Errorf("expected tags %v, got %v", template.Tags, rendered.Tags)
+	}
+}
+
+func TestRenderWithParameters(t *testing.T) {
+	template := &Template{Meta: Meta{
+		Title:      "Upgrade {{ .component }}",
+		Parameters: []Parameter{{Name: "component", Required: true}},
+	}}
+
+	rendered, err := template.Render(map[string]string{"component": "api"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rendered.Title != "Upgrade api" {
+		t.Errorf("expected title 'Upgrade api', got '%s'", rendered.Title)
+	}
+}
+
+func TestRenderKeepsRawHTMLOfTasksOnly(t *testing.T) {
+	template := &Template{
+		Meta: Meta{
+			Tasks: []Task{{Title: "Review", Description: "Check <b>all</b> of it"}},
+		},
+		body: "Intro <b>bold</b>\n",
+	}
+
+	rendered, err := template.Render(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := "<p>Check <b>all</b> of it</p>\n"; rendered.Tasks[0].Description != expected {
+		t.Errorf("expected task description %q, got %q", expected, rendered.Tasks[0].Description)
+	}
+
+	if expected := "<p>Intro <!-- raw HTML omitted -->bold<!-- raw HTML omitted --></p>\n"; rendered.Description != expected {
+		t.Errorf("expected description %q, got %q", expected, rendered.Description)
+	}
+}
@@ -0,0 +1,222 @@
+package crusado
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// DefaultTasksSection is the heading of the markdown section that contains the
+// tasks of a template, if the template doesn't specify another one.
+const DefaultTasksSection = "Tasks"
+
+// section is a part of a markdown document that starts with a heading and ends
+// right before the next heading of the same or a higher level. All positions
+// are byte offsets into the document.
+type section struct {
+	level int
+	title string
+
+	// start is the beginning of the line containing the heading
+	start int
+
+	// contentStart is the beginning of the line following the heading
+	contentStart int
+
+	// end is the beginning of the next section of the same or a higher level
+	end int
+}
+
+// content returns the markdown between the heading and the end of the section.
+func (s *section) content(source []byte) string {
+	return strings.TrimSpace(string(source[s.contentStart:s.end]))
+}
+
+// parseSections returns all sections of the given markdown document in order
+// of appearance. Nested sections are part of the sections they are nested in.
+// Only ATX headings (e.g. "## Heading") are considered.
+func parseSections(source []byte) []section {
+	doc := newMarkdown().Parser().Parse(text.NewReader(source))
+	sections := []section{}
+
+	for node := doc.FirstChild(); node != nil; node = node.NextSibling() {
+		heading, ok := node.(*ast.Heading)
+		if !ok || heading.Lines().Len() == 0 {
+			continue
+		}
+
+		textStart := heading.Lines().At(0).Start
+		textStop := heading.Lines().At(heading.Lines().Len() - 1).Stop
+		lineStart := bytes.LastIndexByte(source[:textStart], '\n') + 1
+
+		// setext headings are underlined instead, so their content doesn't
+		// start on the line following their text
+		if !bytes.HasPrefix(bytes.TrimLeft(source[lineStart:textStart], " "), []byte("#")) {
+			continue
+		}
+
+		s := section{
+			level:        heading.Level,
+			title:        strings.TrimSpace(string(heading.Text(source))),
+			start:        lineStart,
+			contentStart: len(source),
+			end:          len(source),
+		}
+
+		if i := bytes.IndexByte(source[textStop:], '\n'); i != -1 {
+			s.contentStart = textStop + i + 1
+		}
+
+		sections = append(sections, s)
+	}
+
+	for i := range sections {
+		for j := i + 1; j < len(sections); j++ {
+			if sections[j].level <= sections[i].level {
+				sections[i].end = sections[j].start
+				break
+			}
+		}
+	}
+
+	return sections
+}
+
+// extractTaskSections finds the section with the given title and turns each of
+// its direct subsections into a task, using the subsection's heading as title
+// and its content as description. Returns the tasks and the markdown without
+// the tasks section.
+func extractTaskSections(body, title string) ([]Task, string) {
+	source := []byte(body)
+	sections := parseSections(source)
+
+	for i := range sections {
+		tasksSection := sections[i]
+		if !strings.EqualFold(tasksSection.title, title) {
+			continue
+		}
+
+		tasks := []Task{}
+		for j := i + 1; j < len(sections) && sections[j].start < tasksSection.end; j++ {
+			if sections[j].level != tasksSection.level+1 {
+				continue
+			}
+
+			tasks = append(tasks, Task{
+				Title:       sections[j].title,
+				Description: sections[j].content(source),
+				Source:      MarkdownTaskSource,
+			})
+		}
+
+		return tasks, body[:tasksSection.start] + body[tasksSection.end:]
+	}
+
+	return nil, body
+}
+
+// extractFieldSections moves the content of all markdown sections that are
+// mapped to a work item field out of the description and into RichTextFields.
+// Sections are matched by their heading, regardless of their level.
+func (t *Template) extractFieldSections() error {
+	if t.body == "" {
+		return nil
+	}
+
+	body, sources := extractSections(t.body, sectionMapping(t.defaultSections(t.Type), t.Sections))
+	if len(sources) == 0 {
+		return nil
+	}
+
+	t.body = body
+	t.richTextSources = sources
+
+	var err error
+	if t.RichTextFields, err = convertSections(sources); err != nil {
+		return err
+	}
+
+	description, err := convertMarkdown([]byte(t.body))
+	if err != nil {
+		return err
+	}
+	t.Description = description
+
+	return nil
+}
+
+// defaultSections returns the mapping of section headings to work item fields
+// of the given type, see Service.SectionFields.
+func (t *Template) defaultSections(templateType Type) map[string]string {
+	if t.sectionFields == nil {
+		return DefaultSectionFields[templateType]
+	}
+
+	return t.sectionFields[templateType]
+}
+
+// sectionMapping merges the given mappings of headings to work item fields into
+// one with lower-cased headings, see extractSections. Later mappings override
+// earlier ones regardless of the case of their headings.
+func sectionMapping(mappings ...map[string]string) map[string]string {
+	merged := map[string]string{}
+
+	for _, mapping := range mappings {
+		for heading, field := range mapping {
+			merged[strings.ToLower(heading)] = field
+		}
+	}
+
+	return merged
+}
+
+// extractSections removes all sections whose heading is mapped to a work item
+// field from the markdown body, ignoring case. The mapping has to be built by
+// sectionMapping. Returns the remaining markdown and the content of the first
+// section per field.
+func extractSections(markdown string, mapping map[string]string) (string, map[string]string) {
+	source := []byte(markdown)
+	body := ""
+	position := 0
+	sources := map[string]string{}
+
+	for _, s := range parseSections(source) {
+		field := mapping[strings.ToLower(s.title)]
+		if field == "" || s.start < position {
+			continue
+		}
+
+		if _, exists := sources[field]; !exists {
+			sources[field] = s.content(source)
+		}
+
+		body += markdown[position:s.start]
+		position = s.end
+	}
+
+	return body + markdown[position:], sources
+}
+
+// convertSections converts the markdown content of each field to the format
+// the field expects, which is HTML for all fields but the steps of test cases.
+func convertSections(sources map[string]string) (map[string]string, error) {
+	fields := map[string]string{}
+
+	for field, content := range sources {
+		var err error
+
+		if field == StepsField {
+			fields[field], err = convertSteps([]byte(content))
+		} else {
+			fields[field], err = convertMarkdown([]byte(content))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,156 @@
+package crusado
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractSections(t *testing.T) {
+	defaults := map[string]string{"Acceptance Criteria": "AC", "Notes": "Notes"}
+
+	tests := []struct {
+		name            string
+		mapping         map[string]string
+		expectedBody    string
+		expectedSources map[string]string
+	}{
+		{
+			name:            "defaults ignoring case",
+			mapping:         sectionMapping(defaults),
+			expectedBody:    "Intro\n\n",
+			expectedSources: map[string]string{"AC": "Works", "Notes": "None"},
+		},
+		{
+			name:            "template overrides defaults regardless of case",
+			mapping:         sectionMapping(defaults, map[string]string{"acceptance criteria": "", "NOTES": "Custom"}),
+			expectedBody:    "Intro\n\n## acceptance CRITERIA\n\nWorks\n\n",
+			expectedSources: map[string]string{"Custom": "None"},
+		},
+	}
+
+	markdown := "Intro\n\n## acceptance CRITERIA\n\nWorks\n\n## Notes\n\nNone\n"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, sources := extractSections(markdown, tt.mapping)
+
+			if body != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, body)
+			}
+
+			if !reflect.DeepEqual(sources, tt.expectedSources) {
+				t.Errorf("expected sources %v, got %v", tt.expectedSources, sources)
+			}
+		})
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	markdown := "# Title\n\nIntro\n\n## Tasks\n\n### Review\nCheck it\n\nSetext\n------\n\nMore\n\n## Notes\n"
+
+	sections := parseSections([]byte(markdown))
+
+	expected := []struct {
+		level   int
+		title   string
+		content string
+	}{
+		{level: 1, title: "Title", content: "Intro\n\n## Tasks\n\n### Review\nCheck it\n\nSetext\n------\n\nMore\n\n## Notes"},
+		{level: 2, title: "Tasks", content: "### Review\nCheck it\n\nSetext\n------\n\nMore"},
+		{level: 3, title: "Review", content: "Check it\n\nSetext\n------\n\nMore"},
+		{level: 2, title: "Notes", content: ""},
+	}
+
+	if len(sections) != len(expected) {
+		t.Fatalf("expected %d sections, got %d: %+v", len(expected), len(sections), sections)
+	}
+
+	for i := range expected {
+		s := sections[i]
+		if s.level != expected[i].level || s.title != expected[i].title || s.content([]byte(markdown)) != expected[i].content {
+			t.Errorf("expected section %+v, got level %d, title '%s' and content %q", expected[i], s.level, s.title, s.content([]byte(markdown)))
+		}
+	}
+}
+
+func TestExtractTaskSections(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		title         string
+		expectedTasks []Task
+		expectedBody  string
+	}{
+		{
+			name:  "subsections ignoring case of the title",
+			body:  "Intro\n\n## tasks\n\n### Implement\n\nWrite *code*\n\n#### Details\n\nMore\n\n### Review\n\n## Notes\n\nNone\n",
+			title: DefaultTasksSection,
+			expectedTasks: []Task{
+				{Title: "Implement", Description: "Write *code*\n\n#### Details\n\nMore", Source: MarkdownTaskSource},
+				{Title: "Review", Description: "", Source: MarkdownTaskSource},
+			},
+			expectedBody: "Intro\n\n## Notes\n\nNone\n",
+		},
+		{
+			name:          "custom title",
+			body:          "Intro\n\n# To Do\n\n## Deploy\n\nShip it\n",
+			title:         "To Do",
+			expectedTasks: []Task{{Title: "Deploy", Description: "Ship it", Source: MarkdownTaskSource}},
+			expectedBody:  "Intro\n\n",
+		},
+		{
+			name:         "no tasks section",
+			body:         "Intro\n\n## Notes\n\nNone\n",
+			title:        DefaultTasksSection,
+			expectedBody: "Intro\n\n## Notes\n\nNone\n",
+		},
+		{
+			name:         "setext headings aren't sections",
+			body:         "Intro\n\nTasks\n-----\n\nReview\n",
+			title:        DefaultTasksSection,
+			expectedBody: "Intro\n\nTasks\n-----\n\nReview\n",
+		},
+		{
+			name:          "setext headings are part of the task",
+			body:          "## Tasks\n\n### Review\n\nChecklist\n---------\n\nDone\n",
+			title:         DefaultTasksSection,
+			expectedTasks: []Task{{Title: "Review", Description: "Checklist\n---------\n\nDone", Source: MarkdownTaskSource}},
+			expectedBody:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, body := extractTaskSections(tt.body, tt.title)
+
+			if !reflect.DeepEqual(tasks, tt.expectedTasks) {
+				t.Errorf("expected tasks %+v, got %+v", tt.expectedTasks, tasks)
+			}
+
+			if body != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestMergeSectionTasks(t *testing.T) {
+	frontmatterTasks := []Task{
+		{Title: "Implement", Description: "From frontmatter", Source: FrontmatterTaskSource},
+		{Title: "Review", Source: FrontmatterTaskSource},
+	}
+	sectionTasks := []Task{
+		{Title: "Review", Description: "From section", Source: MarkdownTaskSource},
+		{Title: "Deploy", Description: "Ship it", Source: MarkdownTaskSource},
+	}
+
+	expected := []Task{
+		{Title: "Implement", Description: "From frontmatter", Source: FrontmatterTaskSource},
+		{Title: "Review", Description: "From section", Source: FrontmatterTaskSource + ", " + MarkdownTaskSource},
+		{Title: "Deploy", Description: "Ship it", Source: MarkdownTaskSource},
+	}
+
+	if merged := mergeSectionTasks(frontmatterTasks, sectionTasks); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+}
@@ -5,14 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 	"go.abhg.dev/goldmark/frontmatter"
 	"gopkg.in/yaml.v2"
 )
@@ -23,7 +27,17 @@ var (
 
 type Service struct {
 	TemplatesDirectory string
-	templates          []Template
+
+	// CustomTypes can be used by templates in addition to AvailableTypes
+	CustomTypes []Type
+
+	// SectionFields maps headings of markdown sections to the work item fields
+	// they are written to, per template type. Defaults to DefaultSectionFields,
+	// but should only contain fields the work item types templates are created
+	// as actually have
+	SectionFields map[Type]map[string]string
+
+	templates []Template
 }
 
 type FileType string
@@ -39,12 +53,38 @@ func (s *Service) GetAll() ([]Template, error) {
 	}
 
 	sort.Slice(s.templates, func(i, j int) bool {
+		if s.templates[i].Namespace != s.templates[j].Namespace {
+			return s.templates[i].Namespace < s.templates[j].Namespace
+		}
 		return s.templates[i].Name < s.templates[j].Name
 	})
 
 	return s.templates, nil
 }
 
+// GetAllInNamespace returns all templates that live in the given namespace or
+// in any of its nested namespaces. An empty namespace returns all templates.
+func (s *Service) GetAllInNamespace(namespace string) ([]Template, error) {
+	all, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace = strings.Trim(namespace, "/")
+	if namespace == "" {
+		return all, nil
+	}
+
+	filtered := []Template{}
+	for i := range all {
+		if all[i].Namespace == namespace || strings.HasPrefix(all[i].Namespace, namespace+"/") {
+			filtered = append(filtered, all[i])
+		}
+	}
+
+	return filtered, nil
+}
+
 func (s *Service) GetByName(name string) (*Template, error) {
 	if err := s.loadTemplatesFromDir(); err != nil {
 		return nil, err
@@ -66,34 +106,129 @@ func (s *Service) loadTemplatesFromDir() error {
 
 	s.templates = []Template{}
 
-	entries, err := os.ReadDir(s.TemplatesDirectory)
-	if err != nil {
+	if err := s.parseTemplateFiles(); err != nil {
 		return err
 	}
 
-	for _, e := range entries {
-		// TODO support recursively reading templates from subdirectories
+	if err := s.resolveTemplates(); err != nil {
+		return err
+	}
+
+	return validateResolvedTemplateList(s.templates, s.Types())
+}
+
+// parseTemplateFiles parses all template files in the templates directory and
+// its subdirectories, skipping hidden and reserved directories. Files that
+// can't be parsed are logged and skipped.
+func (s *Service) parseTemplateFiles() error {
+	return filepath.WalkDir(s.TemplatesDirectory, func(filePath string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
 		if e.IsDir() {
-			continue
+			// skip hidden directories like .git and the fragments directory,
+			// but never the root itself
+			hidden := filePath != s.TemplatesDirectory && strings.HasPrefix(e.Name(), ".")
+			if hidden || filePath == filepath.Join(s.TemplatesDirectory, FragmentsDirectory) {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
 		supported, fileType := hasSupportedFileType(e.Name())
 
 		if !supported {
-			continue
+			return nil
 		}
 
-		if err := s.parseFile(e.Name(), fileType); err != nil {
+		relativePath, err := filepath.Rel(s.TemplatesDirectory, filePath)
+		if err != nil {
+			return err
+		}
+
+		if err := s.parseFile(filepath.ToSlash(relativePath), fileType); err != nil {
 			log.Printf("error parsing file: %q", err)
-			continue
+		}
+
+		return nil
+	})
+}
+
+// resolveTemplates completes the parsed templates by resolving their fragments
+// and inheritance, and by mapping their sections to fields.
+func (s *Service) resolveTemplates() error {
+	fragments, err := loadFragments(s.TemplatesDirectory)
+	if err != nil {
+		return err
+	}
+
+	if err := resolveFragments(s.templates, fragments); err != nil {
+		return err
+	}
+
+	// inheritance has to be resolved before validating the individual templates,
+	// as their fields might be inherited from their parents. It can't be
+	// resolved for unknown or cyclic parents though, and validating templates
+	// that miss their inherited fields would only report follow-up errors, so
+	// invalid extends are reported on their own
+	if err := ValidateExtends(s.templates); err != nil {
+		return err
+	}
+
+	// YAML templates that wrap the description of a markdown template take
+	// over its sections while inheriting already
+	sectionFields := s.SectionFields
+	if sectionFields == nil {
+		sectionFields = DefaultSectionFields
+	}
+
+	for i := range s.templates {
+		s.templates[i].sectionFields = sectionFields
+	}
+
+	if err := resolveInheritance(s.templates); err != nil {
+		return err
+	}
+
+	// sections are mapped to fields depending on the type, which might have
+	// been inherited
+	for i := range s.templates {
+		if err := s.templates[i].extractFieldSections(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Types returns all types templates can use, the available ones followed by
+// the custom ones.
+func (s *Service) Types() []Type {
+	types := append([]Type{}, AvailableTypes...)
+
+	for _, customType := range s.CustomTypes {
+		known := false
+		for i := range types {
+			if types[i] == customType {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			types = append(types, customType)
 		}
 	}
 
-	return ValidateTemplateList(s.templates)
+	return types
 }
 
-func (s *Service) parseFile(name string, fileType FileType) error {
-	filePath := path.Join(s.TemplatesDirectory, name)
+// parseFile parses the file at the given path, which is relative to the
+// templates directory. The directory part of that path becomes the namespace of
+// all templates found in the file.
+func (s *Service) parseFile(relativePath string, fileType FileType) error {
+	filePath := filepath.Join(s.TemplatesDirectory, filepath.FromSlash(relativePath))
 	file, err := os.Open(filePath)
 	if err != nil {
 		return fmt.Errorf("could not open %s: %q", filePath, err)
@@ -123,19 +258,25 @@ func (s *Service) parseFile(name string, fileType FileType) error {
 		return fmt.Errorf("could not parse %s: %q", filePath, err)
 	}
 
+	namespace := path.Dir(relativePath)
+	if namespace == "." {
+		namespace = ""
+	}
+
+	for i := range tpls {
+		tpls[i].Namespace = namespace
+		tpls[i].FilePath = filePath
+		tpls[i].Name = path.Join(namespace, tpls[i].Name)
+	}
+
 	s.templates = append(s.templates, tpls...)
 
 	return nil
 }
 
 func parseMarkdown(content []byte) ([]Template, error) {
-	md := goldmark.New(goldmark.WithExtensions(&frontmatter.Extender{}))
-
-	var buf bytes.Buffer
 	ctx := parser.NewContext()
-	if err := md.Convert(content, &buf, parser.WithContext(ctx)); err != nil {
-		return nil, err
-	}
+	newMarkdown().Parser().Parse(text.NewReader(content), parser.WithContext(ctx))
 
 	meta := Meta{}
 	metaRaw := frontmatter.Get(ctx)
@@ -148,14 +289,102 @@ func parseMarkdown(content []byte) ([]Template, error) {
 		return nil, err
 	}
 
+	for i := range meta.Tasks {
+		meta.Tasks[i].Source = FrontmatterTaskSource
+	}
+
+	tasksSection := meta.TasksSection
+	if tasksSection == "" {
+		tasksSection = DefaultTasksSection
+	}
+
+	sectionTasks, body := extractTaskSections(markdownBody(content), tasksSection)
+	meta.Tasks = mergeSectionTasks(meta.Tasks, sectionTasks)
+
+	description, err := convertMarkdown([]byte(body))
+	if err != nil {
+		return nil, err
+	}
+
 	return []Template{
 		{
 			Meta:        meta,
-			Description: buf.String(),
+			Description: description,
+			body:        body,
 		},
 	}, nil
 }
 
+// mergeSectionTasks appends the tasks defined in markdown sections to the ones
+// from the frontmatter. If a task with the same title exists in the
+// frontmatter, the description of the section is used for that task instead.
+func mergeSectionTasks(frontmatterTasks, sectionTasks []Task) []Task {
+	merged := frontmatterTasks
+
+	for _, task := range sectionTasks {
+		found := false
+
+		for i := range merged {
+			if merged[i].Title == task.Title {
+				merged[i].Description = task.Description
+				merged[i].Source = FrontmatterTaskSource + ", " + MarkdownTaskSource
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			merged = append(merged, task)
+		}
+	}
+
+	return merged
+}
+
+// markdownBody returns the markdown content below the YAML frontmatter.
+func markdownBody(content []byte) string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return string(content)
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Join(lines[i+1:], "")
+		}
+	}
+
+	return ""
+}
+
+// convertMarkdown converts the given markdown content to HTML, ignoring any
+// frontmatter.
+func convertMarkdown(content []byte) (string, error) {
+	var buf bytes.Buffer
+	if err := newMarkdown().Convert(content, &buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func newMarkdown() goldmark.Markdown {
+	return goldmark.New(goldmark.WithExtensions(&frontmatter.Extender{}))
+}
+
+// convertTaskMarkdown converts the given task description to HTML like
+// convertMarkdown, but keeps raw HTML, as task descriptions have always been
+// passed on unchanged before they were converted.
+func convertTaskMarkdown(content []byte) (string, error) {
+	var buf bytes.Buffer
+	md := goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))
+	if err := md.Convert(content, &buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
 // parseYAML can return multiple templates, due to how the profile YAML was
 // designed in the first iteration of crusado
 func parseYAML(content []byte) ([]Template, error) {
@@ -165,7 +394,14 @@ func parseYAML(content []byte) ([]Template, error) {
 		return nil, err
 	}
 
-	return templateMapList["templates"], nil
+	templates := templateMapList["templates"]
+	for i := range templates {
+		for j := range templates[i].Tasks {
+			templates[i].Tasks[j].Source = YAMLTaskSource
+		}
+	}
+
+	return templates, nil
 }
 
 func hasSupportedFileType(fileName string) (bool, FileType) {
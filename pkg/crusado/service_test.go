@@ -0,0 +1,188 @@
+package crusado
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thediveo/klo"
+)
+
+// writeTemplates writes a markdown template for each of the given paths into a
+// new templates directory, named after the file.
+func writeTemplates(t *testing.T, paths ...string) string {
+	t.Helper()
+
+	files := map[string]string{}
+
+	for _, path := range paths {
+		name := filepath.Base(path[:len(path)-len(filepath.Ext(path))])
+		files[path] = fmt.Sprintf("---\nname: %s\ntype: UserStory\ntitle: %s\n---\n\nDescription\n", name, name)
+	}
+
+	return writeFiles(t, files)
+}
+
+func templateNames(templates []Template) []string {
+	names := []string{}
+	for i := range templates {
+		names = append(names, templates[i].Name)
+	}
+
+	return names
+}
+
+func TestGetAllSkipsHiddenAndFragmentsDirectories(t *testing.T) {
+	dir := writeTemplates(t,
+		"story.md",
+		"backend/api.md",
+		"_drafts/draft.md",
+		"backend/_fragments/nested.md",
+		".git/hidden.md",
+		"backend/.cache/cached.md",
+		FragmentsDirectory+"/footer.md",
+	)
+
+	service := &Service{TemplatesDirectory: dir}
+
+	templates, err := service.GetAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"story", "_drafts/draft", "backend/api", "backend/_fragments/nested"}
+	if names := templateNames(templates); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestGetAllInNamespace(t *testing.T) {
+	dir := writeTemplates(t,
+		"story.md",
+		"a/one.md",
+		"a/b/two.md",
+		"ab/three.md",
+	)
+
+	tests := []struct {
+		name      string
+		namespace string
+		expected  []string
+	}{
+		{name: "all", namespace: "", expected: []string{"story", "a/one", "a/b/two", "ab/three"}},
+		{name: "root only", namespace: "/", expected: []string{"story", "a/one", "a/b/two", "ab/three"}},
+		{name: "nested namespaces", namespace: "a", expected: []string{"a/one", "a/b/two"}},
+		{name: "trimmed slashes", namespace: "/a/", expected: []string{"a/one", "a/b/two"}},
+		{name: "nested namespace only", namespace: "a/b", expected: []string{"a/b/two"}},
+		{name: "prefix of another namespace", namespace: "ab", expected: []string{"ab/three"}},
+		{name: "unknown namespace", namespace: "b", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{TemplatesDirectory: dir}
+
+			templates, err := service.GetAllInNamespace(tt.namespace)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if names := templateNames(templates); !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		customTypes []Type
+		expected    []Type
+	}{
+		{name: "available types only", expected: AvailableTypes},
+		{
+			name:        "custom types last",
+			customTypes: []Type{"Risk", "Spike"},
+			expected:    append(append([]Type{}, AvailableTypes...), "Risk", "Spike"),
+		},
+		{
+			name:        "without duplicates",
+			customTypes: []Type{"Risk", UserStoryType, "Risk"},
+			expected:    append(append([]Type{}, AvailableTypes...), "Risk"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{CustomTypes: tt.customTypes}
+
+			if types := service.Types(); !reflect.DeepEqual(types, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, types)
+			}
+		})
+	}
+}
+
+func TestGetAllAcceptsCustomTypes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"risk.md": "---\nname: risk\ntype: Risk\ntitle: Risk\n---\n\nDescription\n",
+	})
+
+	service := &Service{TemplatesDirectory: dir, CustomTypes: []Type{"Risk"}}
+
+	templates, err := service.GetAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if names := templateNames(templates); !reflect.DeepEqual(names, []string{"risk"}) {
+		t.Errorf("expected [risk], got %v", names)
+	}
+}
+
+func TestPrinterSpecsShowNamespaces(t *testing.T) {
+	dir := writeTemplates(t, "story.md", "backend/api.md")
+
+	service := &Service{TemplatesDirectory: dir}
+
+	templates, err := service.GetAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{format: "", expected: []string{"story", "backend/api"}},
+		{format: "wide", expected: []string{"NAME NAMESPACE TYPE", "story UserStory", "backend/api backend UserStory"}},
+		{format: "json", expected: []string{`"name": "backend/api"`, `"namespace": "backend"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run("format "+tt.format, func(t *testing.T) {
+			printer, err := klo.PrinterFromFlag(tt.format, &PrinterSpecs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, templates); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// columns are aligned with a varying number of spaces
+			output := strings.Join(strings.Fields(buf.String()), " ")
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package crusado
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// StepsField is the reference name of the field holding the steps of a test
+// case.
+const StepsField = "Microsoft.VSTS.TCM.Steps"
+
+// expectedPrefix marks the expected result of a test step, e.g.
+// "Expected: the user is logged in".
+var expectedPrefix = regexp.MustCompile(`(?i)^\s*expected:\s*`)
+
+// testStep is a single step of a test case. Both action and expected result
+// are markdown.
+type testStep struct {
+	action   string
+	expected string
+}
+
+// parseSteps turns each item of the first ordered list of the markdown into a
+// test step. A line of the item, or a nested list item, starting with
+// "Expected:" begins the expected result of the step.
+func parseSteps(source []byte) []testStep {
+	doc := newMarkdown().Parser().Parse(text.NewReader(source))
+
+	for node := doc.FirstChild(); node != nil; node = node.NextSibling() {
+		list, ok := node.(*ast.List)
+		if !ok || !list.IsOrdered() {
+			continue
+		}
+
+		steps := []testStep{}
+		for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+			steps = append(steps, parseStep(item, source))
+		}
+
+		return steps
+	}
+
+	return nil
+}
+
+func parseStep(item ast.Node, source []byte) testStep {
+	action, expected := []string{}, []string{}
+	inExpected := false
+
+	for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+		// nested items contribute to the expected result only if they start
+		// with the prefix, all other nested lists are part of the action
+		if nested, ok := child.(*ast.List); ok {
+			for nestedItem := nested.FirstChild(); nestedItem != nil; nestedItem = nestedItem.NextSibling() {
+				lines := nodeLines(nestedItem, source)
+				if len(lines) > 0 && expectedPrefix.MatchString(lines[0]) {
+					lines[0] = expectedPrefix.ReplaceAllString(lines[0], "")
+					expected = append(expected, lines...)
+					continue
+				}
+
+				action = append(action, "* "+strings.Join(lines, "\n"))
+			}
+			continue
+		}
+
+		for _, line := range nodeLines(child, source) {
+			if expectedPrefix.MatchString(line) {
+				inExpected = true
+				line = expectedPrefix.ReplaceAllString(line, "")
+			}
+
+			if inExpected {
+				expected = append(expected, line)
+			} else {
+				action = append(action, line)
+			}
+		}
+	}
+
+	return testStep{
+		action:   strings.TrimSpace(strings.Join(action, "\n")),
+		expected: strings.TrimSpace(strings.Join(expected, "\n")),
+	}
+}
+
+// nodeLines returns the source lines of the node, or of its first child that
+// has lines, e.g. the paragraph of a list item.
+func nodeLines(node ast.Node, source []byte) []string {
+	for node != nil && node.Lines().Len() == 0 {
+		node = node.FirstChild()
+	}
+
+	if node == nil {
+		return nil
+	}
+
+	lines := []string{}
+	for i := 0; i < node.Lines().Len(); i++ {
+		segment := node.Lines().At(i)
+		lines = append(lines, strings.TrimRight(string(segment.Value(source)), "\n"))
+	}
+
+	return lines
+}
+
+// convertSteps converts the ordered list of the markdown to the XML format of
+// the steps of a test case. Actions and expected results are converted to HTML.
+// Steps with an expected result are validation steps, the others action steps.
+func convertSteps(source []byte) (string, error) {
+	steps := parseSteps(source)
+
+	// step IDs start at 2, like the ones of steps created in Azure DevOps
+	last := 0
+	if len(steps) > 0 {
+		last = len(steps) + 1
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<steps id="0" last="%d">`, last)
+
+	for i, step := range steps {
+		action, err := convertMarkdown([]byte(step.action))
+		if err != nil {
+			return "", err
+		}
+
+		expected := ""
+		stepType := "ActionStep"
+
+		if step.expected != "" {
+			stepType = "ValidateStep"
+
+			if expected, err = convertMarkdown([]byte(step.expected)); err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Fprintf(&buf, `<step id="%d" type="%s">`, i+2, stepType)
+		writeParameterizedString(&buf, strings.TrimSpace(action))
+		writeParameterizedString(&buf, strings.TrimSpace(expected))
+		buf.WriteString(`<description/></step>`)
+	}
+
+	buf.WriteString(`</steps>`)
+
+	return buf.String(), nil
+}
+
+func writeParameterizedString(buf *bytes.Buffer, html string) {
+	buf.WriteString(`<parameterizedString isformatted="true">`)
+	// the HTML is escaped, as it's the text content of the element
+	_ = xml.EscapeText(buf, []byte(html))
+	buf.WriteString(`</parameterizedString>`)
+}
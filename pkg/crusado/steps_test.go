@@ -0,0 +1,101 @@
+package crusado
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []testStep
+	}{
+		{
+			name:     "no ordered list",
+			source:   "Some text\n\n* not a step\n",
+			expected: nil,
+		},
+		{
+			name:   "expected result on its own line",
+			source: "1. Open the login page\n   Expected: the form is shown\n2. Log in\n",
+			expected: []testStep{
+				{action: "Open the login page", expected: "the form is shown"},
+				{action: "Log in", expected: ""},
+			},
+		},
+		{
+			name:   "expected result as nested item",
+			source: "1. Log in\n   * with *valid* credentials\n   * expected: the dashboard is shown\n",
+			expected: []testStep{
+				{action: "Log in\n* with *valid* credentials", expected: "the dashboard is shown"},
+			},
+		},
+		{
+			name:   "only the first ordered list",
+			source: "Intro\n\n1. First\n\nOutro\n\n1. Ignored\n",
+			expected: []testStep{
+				{action: "First", expected: ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if steps := parseSteps([]byte(tt.source)); !reflect.DeepEqual(steps, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, steps)
+			}
+		})
+	}
+}
+
+func TestConvertSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "no steps",
+			source:   "No list here",
+			expected: `<steps id="0" last="0"></steps>`,
+		},
+		{
+			name:   "action and validation steps",
+			source: "1. Open the **login** page\n2. Log in\n   Expected: the dashboard is shown\n",
+			expected: `<steps id="0" last="3">` +
+				`<step id="2" type="ActionStep">` +
+				`<parameterizedString isformatted="true">&lt;p&gt;Open the &lt;strong&gt;login&lt;/strong&gt; page&lt;/p&gt;</parameterizedString>` +
+				`<parameterizedString isformatted="true"></parameterizedString>` +
+				`<description/></step>` +
+				`<step id="3" type="ValidateStep">` +
+				`<parameterizedString isformatted="true">&lt;p&gt;Log in&lt;/p&gt;</parameterizedString>` +
+				`<parameterizedString isformatted="true">&lt;p&gt;the dashboard is shown&lt;/p&gt;</parameterizedString>` +
+				`<description/></step>` +
+				`</steps>`,
+		},
+		{
+			name:   "special characters",
+			source: "1. Enter `a < b & c`\n",
+			expected: `<steps id="0" last="2">` +
+				`<step id="2" type="ActionStep">` +
+				`<parameterizedString isformatted="true">&lt;p&gt;Enter &lt;code&gt;a &amp;lt; b &amp;amp; c&lt;/code&gt;&lt;/p&gt;</parameterizedString>` +
+				`<parameterizedString isformatted="true"></parameterizedString>` +
+				`<description/></step>` +
+				`</steps>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := convertSteps([]byte(tt.source))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if steps != tt.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tt.expected, steps)
+			}
+		})
+	}
+}
@@ -7,17 +7,42 @@ type Template struct {
 
 	// Description is the content of the WorkItem. Can contain HTML
 	Description string `yaml:"description" json:"description"`
+
+	// Namespace is derived from the subdirectory the template was loaded from,
+	// relative to the templates directory. Empty for top-level templates
+	Namespace string `yaml:"-" json:"namespace,omitempty"`
+
+	// FilePath is the path of the file the template was loaded from
+	FilePath string `yaml:"-" json:"filePath,omitempty"`
+
+	// RichTextFields maps field reference names to the HTML content of the
+	// markdown sections that are mapped to these fields
+	RichTextFields map[string]string `yaml:"-" json:"richTextFields,omitempty"`
+
+	// richTextSources holds the raw markdown of the RichTextFields
+	richTextSources map[string]string
+
+	// sectionFields holds the default sections of the service the template
+	// was loaded by, see Service.SectionFields
+	sectionFields map[Type]map[string]string
+
+	// body holds the raw markdown below the frontmatter of templates loaded
+	// from markdown files, so the description can be rendered before it is
+	// converted to HTML
+	body string
 }
 
 // Meta represents the Metadata associated with a Crusado template
 type Meta struct {
-	// Name is the unique name of the template, used in commands
+	// Name is the unique name of the template, used in commands. Once loaded,
+	// it is prefixed with the template's namespace, e.g. backend/db-migration
 	Name string `yaml:"name" json:"name"`
 
 	// Summary provides a short synopsis for the template
 	Summary string `yaml:"summary" json:"summary"`
 
-	// Type identifies the template as one of [User Story, Bug]
+	// Type identifies the template as one of AvailableTypes or one of the
+	// custom types of the Service
 	Type Type `yaml:"type" json:"type"`
 
 	// Title is the resulting title of the work item in Azure DevOps
@@ -25,27 +50,195 @@ type Meta struct {
 
 	// Tasks is a slice of individual tasks that are part of the template
 	Tasks []Task `yaml:"tasks" json:"tasks"`
+
+	// Children are work items created underneath the resulting work item,
+	// each with tasks and children of their own
+	Children []Child `yaml:"children" json:"children,omitempty"`
+
+	// TasksSection is the heading of the markdown section that contains tasks
+	// as subsections. Defaults to DefaultTasksSection
+	TasksSection string `yaml:"tasksSection" json:"tasksSection,omitempty"`
+
+	// Fields maps reference names of work item fields to their values, e.g.
+	// Microsoft.VSTS.Common.Priority: 1
+	Fields map[string]string `yaml:"fields" json:"fields,omitempty"`
+
+	// AreaPath is the area path of the resulting work items, either absolute
+	// or relative to the project. Overrides the configured area path
+	AreaPath string `yaml:"areaPath" json:"areaPath,omitempty"`
+
+	// Tags are added to the resulting work item
+	Tags []string `yaml:"tags" json:"tags,omitempty"`
+
+	// AssignedTo is the email address or display name of the user the
+	// resulting work item is assigned to. Use @me for the user of the PAT
+	AssignedTo string `yaml:"assignedTo" json:"assignedTo,omitempty"`
+
+	// Parent is the ID of an existing work item, e.g. a Feature, the resulting
+	// work item is created underneath
+	Parent int `yaml:"parent" json:"parent,omitempty"`
+
+	// Sections maps headings of markdown sections to the reference names of
+	// the work item fields they are written to, in addition to the defaults in
+	// DefaultSectionFields. Map a heading to an empty string to keep that
+	// section in the description
+	Sections map[string]string `yaml:"sections" json:"sections,omitempty"`
+
+	// Extends is the name of the template this template inherits from
+	Extends string `yaml:"extends" json:"extends,omitempty"`
+
+	// Parameters declares the values that can be rendered into the title,
+	// description and tasks when the template is applied
+	Parameters []Parameter `yaml:"parameters" json:"parameters,omitempty"`
 }
 
 type Task struct {
-	Title       string `yaml:"title" json:"title"`
+	Title string `yaml:"title" json:"title"`
+
+	// ID identifies the task within its template, so other tasks can depend on
+	// it without repeating its title
+	ID string `yaml:"id" json:"id,omitempty"`
+
+	// DependsOn lists the IDs or titles of the tasks of the same template that
+	// have to be done before this task
+	DependsOn []string `yaml:"dependsOn" json:"dependsOn,omitempty"`
+
+	// Description is the markdown content of the task. It is converted to HTML
+	// when the template is applied
 	Description string `yaml:"description" json:"description"`
+
+	// Fields maps reference names of work item fields to their values, e.g.
+	// Microsoft.VSTS.Scheduling.RemainingWork: 4
+	Fields map[string]string `yaml:"fields" json:"fields,omitempty"`
+
+	// Tags are added to the resulting task
+	Tags []string `yaml:"tags" json:"tags,omitempty"`
+
+	// AssignedTo is the email address or display name of the user the
+	// resulting task is assigned to. Use @me for the user of the PAT
+	AssignedTo string `yaml:"assignedTo" json:"assignedTo,omitempty"`
+
+	// Include is the name of a fragment whose tasks replace this task. Only
+	// used while loading templates
+	Include string `yaml:"include" json:"include,omitempty"`
+
+	// Source describes where the task was defined, e.g. in the frontmatter or
+	// in a markdown section
+	Source string `yaml:"-" json:"source,omitempty"`
+
+	// InheritedFrom is the name of the template the task was defined in, if
+	// it was inherited, see Template.Extends
+	InheritedFrom string `yaml:"-" json:"inheritedFrom,omitempty"`
+}
+
+// Origin describes where the task was defined, including the template it was
+// inherited from, e.g. "yaml of base".
+func (t *Task) Origin() string {
+	if t.InheritedFrom == "" {
+		return t.Source
+	}
+
+	return t.Source + " of " + t.InheritedFrom
+}
+
+const (
+	FrontmatterTaskSource = "frontmatter"
+	MarkdownTaskSource    = "markdown section"
+	YAMLTaskSource        = "yaml"
+	FragmentTaskSource    = "fragment"
+)
+
+// SetFields sets the given fields on the template, overriding existing values.
+func (t *Template) SetFields(fields map[string]string) {
+	if len(fields) > 0 && t.Fields == nil {
+		t.Fields = map[string]string{}
+	}
+
+	for ref, value := range fields {
+		t.Fields[ref] = value
+	}
+}
+
+// WorkItemFields returns all fields of the resulting work item besides title
+// and description. Fields set explicitly take precedence over rich text fields
+// from markdown sections, the assignee takes precedence over both.
+func (t *Template) WorkItemFields() map[string]string {
+	fields := map[string]string{}
+
+	for ref, value := range t.RichTextFields {
+		fields[ref] = value
+	}
+
+	for ref, value := range t.Fields {
+		fields[ref] = value
+	}
+
+	if t.AssignedTo != "" {
+		fields[AssignedToField] = t.AssignedTo
+	}
+
+	return fields
+}
+
+// WorkItemFields returns all fields of the resulting task besides title and
+// description.
+func (t *Task) WorkItemFields() map[string]string {
+	fields := map[string]string{}
+
+	for ref, value := range t.Fields {
+		fields[ref] = value
+	}
+
+	if t.AssignedTo != "" {
+		fields[AssignedToField] = t.AssignedTo
+	}
+
+	return fields
 }
 
+// AssignedToField is the reference name of the field holding the assignee.
+const AssignedToField = "System.AssignedTo"
+
 type Type string
 
 const (
-	UserStoryType = Type("UserStory")
-	BugType       = Type("Bug")
-	TaskType      = Type("Task")
+	EpicType       = Type("Epic")
+	FeatureType    = Type("Feature")
+	UserStoryType  = Type("UserStory")
+	BugType        = Type("Bug")
+	IssueType      = Type("Issue")
+	ImpedimentType = Type("Impediment")
+	TestCaseType   = Type("TestCase")
+	TaskType       = Type("Task")
 )
 
+// DefaultSectionFields maps headings of markdown sections to the reference
+// names of the work item fields they are written to, per template type. The
+// fields are the ones of the work item types of the Agile and Scrum processes.
+var DefaultSectionFields = map[Type]map[string]string{
+	UserStoryType: {
+		"Acceptance Criteria": "Microsoft.VSTS.Common.AcceptanceCriteria",
+	},
+	BugType: {
+		"Acceptance Criteria": "Microsoft.VSTS.Common.AcceptanceCriteria",
+		"System Info":         "Microsoft.VSTS.TCM.SystemInfo",
+	},
+	TestCaseType: {
+		"Steps": StepsField,
+	},
+}
+
 var AvailableTypes = []Type{
+	EpicType,
+	FeatureType,
 	UserStoryType,
 	BugType,
+	IssueType,
+	ImpedimentType,
+	TestCaseType,
 }
 
 var PrinterSpecs = klo.Specs{
 	DefaultColumnSpec: "NAME:{.Name},TYPE:{.Type},SUMMARY:{.Summary}",
-	WideColumnSpec:    "NAME:{.Name},TYPE:{.Type},SUMMARY:{.Summary},TITLE:{.Title},TASKS:{.Tasks[*].Title}",
+	WideColumnSpec:    "NAME:{.Name},NAMESPACE:{.Namespace},TYPE:{.Type},SUMMARY:{.Summary},TITLE:{.Title},TAGS:{.Tags[*]},TASKS:{.Tasks[*].Title}",
 }
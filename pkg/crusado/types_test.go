@@ -0,0 +1,102 @@
+package crusado
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateWorkItemFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		template Template
+		expected map[string]string
+	}{
+		{name: "none", expected: map[string]string{}},
+		{
+			name:     "rich text fields",
+			template: Template{RichTextFields: map[string]string{"Custom.Notes": "<p>Notes</p>"}},
+			expected: map[string]string{"Custom.Notes": "<p>Notes</p>"},
+		},
+		{
+			name: "fields override rich text fields",
+			template: Template{
+				Meta:           Meta{Fields: map[string]string{"Custom.Notes": "Fields", "Custom.Team": "Backend"}},
+				RichTextFields: map[string]string{"Custom.Notes": "<p>Notes</p>", "Custom.Risks": "<p>Risks</p>"},
+			},
+			expected: map[string]string{"Custom.Notes": "Fields", "Custom.Team": "Backend", "Custom.Risks": "<p>Risks</p>"},
+		},
+		{
+			name: "assignee overrides both",
+			template: Template{
+				Meta:           Meta{Fields: map[string]string{AssignedToField: "Fields"}, AssignedTo: "jane@example.com"},
+				RichTextFields: map[string]string{AssignedToField: "<p>Notes</p>"},
+			},
+			expected: map[string]string{AssignedToField: "jane@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fields := tt.template.WorkItemFields(); !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+func TestTaskWorkItemFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     Task
+		expected map[string]string
+	}{
+		{name: "none", expected: map[string]string{}},
+		{
+			name:     "fields",
+			task:     Task{Fields: map[string]string{"Microsoft.VSTS.Scheduling.RemainingWork": "4"}},
+			expected: map[string]string{"Microsoft.VSTS.Scheduling.RemainingWork": "4"},
+		},
+		{
+			name:     "assignee overrides fields",
+			task:     Task{Fields: map[string]string{AssignedToField: "Fields"}, AssignedTo: "jane@example.com"},
+			expected: map[string]string{AssignedToField: "jane@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fields := tt.task.WorkItemFields(); !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+func TestSetFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		set      map[string]string
+		expected map[string]string
+	}{
+		{name: "nothing set", fields: nil, set: nil, expected: nil},
+		{name: "without template fields", set: map[string]string{"Custom.Team": "Backend"}, expected: map[string]string{"Custom.Team": "Backend"}},
+		{
+			name:     "overrides template fields",
+			fields:   map[string]string{"Custom.Team": "Frontend", "Custom.Risk": "Low"},
+			set:      map[string]string{"Custom.Team": "Backend"},
+			expected: map[string]string{"Custom.Team": "Backend", "Custom.Risk": "Low"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := Template{Meta: Meta{Fields: tt.fields}}
+			template.SetFields(tt.set)
+
+			if !reflect.DeepEqual(template.Fields, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, template.Fields)
+			}
+		})
+	}
+}
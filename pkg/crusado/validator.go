@@ -3,65 +3,349 @@ package crusado
 import (
 	"errors"
 	"fmt"
+	"path"
+	"regexp"
+	"strings"
 )
 
 var (
-	ErrDuplicateTemplateNames = errors.New("duplicate names for templates")
-	ErrTypeNotSet             = errors.New("template doesn't have type, which is required")
-	ErrInvalidType            = errors.New("specified type is not valid")
+	ErrDuplicateTemplateNames        = errors.New("duplicate names for templates")
+	ErrTypeNotSet                    = errors.New("template doesn't have type, which is required")
+	ErrInvalidType                   = errors.New("specified type is not valid")
+	ErrInvalidParameter              = errors.New("parameter declaration is not valid")
+	ErrUnknownParent                 = errors.New("template extends a template that doesn't exist")
+	ErrInheritanceCycle              = errors.New("templates extend each other in a cycle")
+	ErrIncompatibleParentDescription = errors.New("markdown templates can't wrap the description of YAML templates")
+	ErrDuplicateTaskID               = errors.New("task ID is used more than once")
+	ErrUnknownDependency             = errors.New("task depends on a task that doesn't exist")
+	ErrAmbiguousDependency           = errors.New("task depends on a title shared by multiple tasks")
+	ErrDependencyCycle               = errors.New("tasks depend on each other in a cycle")
+	ErrInvalidChildType              = errors.New("child type must be lower in the hierarchy than its parent")
+	ErrInvalidChildLink              = errors.New("child link is not valid")
 )
 
 // ValidateTemplateList validates the list of templates given as a whole as well
-// as each indiviual template within the list. It returns an error that is
+// as each indiviual template within the list. Templates and their children
+// have to use one of the given types. It returns an error that is
 // constructed using errors.Join().
-func ValidateTemplateList(templateList []Template) error {
+func ValidateTemplateList(templateList []Template, types []Type) error {
+	return errors.Join(ValidateExtends(templateList), validateResolvedTemplateList(templateList, types))
+}
+
+// validateResolvedTemplateList validates the list like ValidateTemplateList,
+// except for the extends, which have to be validated before resolving the
+// inheritance already.
+func validateResolvedTemplateList(templateList []Template, types []Type) error {
 	errs := []error{}
 
 	errs = append(errs, ValidateUniqueName(templateList))
 
 	for i := range templateList {
-		errs = append(errs, ValidateTemplate(&templateList[i]))
+		errs = append(errs, ValidateTemplate(&templateList[i], types))
 	}
 
 	return errors.Join(errs...)
 }
 
+// ValidateUniqueName makes sure no fully qualified template name is used more
+// than once. The error reports the files of all templates sharing a name.
 func ValidateUniqueName(templateList []Template) error {
-	templateNames := map[string]bool{}
+	templatePaths := map[string][]string{}
+	names := []string{}
 	errs := []error{}
 
 	for i := range templateList {
 		name := templateList[i].Name
-		if exists := templateNames[name]; exists {
-			errs = append(errs, fmt.Errorf("%w: name '%s' exists more than once", ErrDuplicateTemplateNames, name))
-		} else {
-			templateNames[name] = true
+		if _, exists := templatePaths[name]; !exists {
+			names = append(names, name)
+		}
+		templatePaths[name] = append(templatePaths[name], templateList[i].FilePath)
+	}
+
+	for _, name := range names {
+		if paths := templatePaths[name]; len(paths) > 1 {
+			errs = append(errs, fmt.Errorf("%w: name '%s' exists more than once: %s", ErrDuplicateTemplateNames, name, strings.Join(paths, ", ")))
 		}
 	}
 
 	return errors.Join(errs...)
 }
 
-// ValidateTemplate validates the given template. It returns an error that is
-// constructed using errors.Join().
-func ValidateTemplate(template *Template) error {
+// ValidateExtends makes sure every template that extends another template
+// refers to an existing one and that no template ends up extending itself.
+func ValidateExtends(templateList []Template) error {
+	errs := []error{}
+
+	for i := range templateList {
+		if templateList[i].Extends == "" {
+			continue
+		}
+
+		template := &templateList[i]
+
+		if template.Extends == template.Name || path.Join(template.Namespace, template.Extends) == template.Name {
+			if findParent(templateList, template) == -1 {
+				errs = append(errs, fmt.Errorf("%w: '%s' extends itself", ErrInheritanceCycle, template.Name))
+				continue
+			}
+		}
+
+		if findParent(templateList, template) == -1 {
+			errs = append(errs, fmt.Errorf("%w: '%s' extends '%s'", ErrUnknownParent, templateList[i].Name, templateList[i].Extends))
+			continue
+		}
+
+		if chain := inheritanceCycle(templateList, i); chain != nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrInheritanceCycle, strings.Join(chain, " -> ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// inheritanceCycle follows the parents of the template at the given index and
+// returns the names along the way if it gets back to that template. Returns
+// nil if there is no cycle through the template.
+func inheritanceCycle(templateList []Template, start int) []string {
+	chain := []string{templateList[start].Name}
+	visited := map[int]bool{start: true}
+
+	for current := start; templateList[current].Extends != ""; {
+		current = findParent(templateList, &templateList[current])
+		if current == -1 {
+			return nil
+		}
+
+		chain = append(chain, templateList[current].Name)
+
+		if current == start {
+			return chain
+		}
+
+		// a cycle that doesn't include the start is reported for its members
+		if visited[current] {
+			return nil
+		}
+		visited[current] = true
+	}
+
+	return nil
+}
+
+// ValidateTemplate validates the given template, which has to use one of the
+// given types. It returns an error that is constructed using errors.Join().
+func ValidateTemplate(template *Template, types []Type) error {
 	var errs []error
 
-	errs = append(errs, ValidateType(template))
+	errs = append(errs, ValidateType(template, types))
+	errs = append(errs, ValidateParameters(template))
+	errs = append(errs, ValidateTaskDependencies(template))
+	errs = append(errs, ValidateChildren(template, types))
 
 	return errors.Join(errs...)
 }
 
-func ValidateType(template *Template) error {
-	if template.Type == "" {
+func ValidateType(template *Template, types []Type) error {
+	return validateType(template.Type, types)
+}
+
+func validateType(templateType Type, types []Type) error {
+	if templateType == "" {
 		return ErrTypeNotSet
 	}
 
-	for i := range AvailableTypes {
-		if template.Type == AvailableTypes[i] {
+	for i := range types {
+		if templateType == types[i] {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("%w: type '%s' should be one of %+v", ErrInvalidType, template.Type, AvailableTypes)
+	return fmt.Errorf("%w: type '%s' should be one of %+v", ErrInvalidType, templateType, types)
+}
+
+// ValidateParameters makes sure all parameters of the template have a unique
+// name and that their defaults satisfy their own restrictions.
+func ValidateParameters(template *Template) error {
+	names := map[string]bool{}
+	errs := []error{}
+
+	for i := range template.Parameters {
+		param := template.Parameters[i]
+
+		if param.Name == "" {
+			errs = append(errs, fmt.Errorf("%w: parameter %d of template '%s' has no name", ErrInvalidParameter, i, template.Name))
+			continue
+		}
+
+		if names[param.Name] {
+			errs = append(errs, fmt.Errorf("%w: parameter '%s' of template '%s' is declared more than once", ErrInvalidParameter, param.Name, template.Name))
+		}
+		names[param.Name] = true
+
+		if param.Pattern != "" {
+			if _, err := regexp.Compile(param.Pattern); err != nil {
+				errs = append(errs, fmt.Errorf("%w: pattern of parameter '%s' of template '%s': %w", ErrInvalidParameter, param.Name, template.Name, err))
+				continue
+			}
+		}
+
+		if param.Min != nil && param.Max != nil && *param.Min > *param.Max {
+			errs = append(errs, fmt.Errorf("%w: min of parameter '%s' of template '%s' is larger than max", ErrInvalidParameter, param.Name, template.Name))
+		}
+
+		if param.Default != "" {
+			if err := param.Validate(param.Default); err != nil {
+				errs = append(errs, fmt.Errorf("%w: default of template '%s': %w", ErrInvalidParameter, template.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateTaskDependencies makes sure task IDs are unique, that every task only
+// depends on existing tasks of the same work item and that the tasks don't
+// depend on each other in a cycle. Covers the tasks of all children, too.
+func ValidateTaskDependencies(template *Template) error {
+	errs := []error{validateTaskDependencies(template.Tasks, fmt.Sprintf("template '%s'", template.Name))}
+
+	var validateChildren func(children []Child)
+	validateChildren = func(children []Child) {
+		for i := range children {
+			owner := fmt.Sprintf("child '%s' of template '%s'", children[i].Title, template.Name)
+			errs = append(errs, validateTaskDependencies(children[i].Tasks, owner))
+			validateChildren(children[i].Children)
+		}
+	}
+	validateChildren(template.Children)
+
+	return errors.Join(errs...)
+}
+
+func validateTaskDependencies(tasks []Task, owner string) error {
+	ids := map[string]bool{}
+	errs := []error{}
+
+	for i := range tasks {
+		id := tasks[i].ID
+		if id == "" {
+			continue
+		}
+
+		if ids[id] {
+			errs = append(errs, fmt.Errorf("%w: '%s' in %s", ErrDuplicateTaskID, id, owner))
+		}
+		ids[id] = true
+	}
+
+	// unknown references are left out of the dependencies, so cycles between
+	// the remaining tasks are still found
+	dependencies, err := taskDependencies(tasks, owner)
+	errs = append(errs, err)
+
+	if chain := dependencyCycle(tasks, dependencies); chain != nil {
+		errs = append(errs, fmt.Errorf("%w: %s in %s", ErrDependencyCycle, strings.Join(chain, " -> "), owner))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateChildren makes sure all children of the template have one of the
+// given types and a valid link. If both a child and its parent are part of
+// HierarchyLevels, a child linked as child has to be lower in the hierarchy
+// than its parent.
+func ValidateChildren(template *Template, types []Type) error {
+	errs := []error{}
+
+	var validate func(parentType Type, children []Child)
+	validate = func(parentType Type, children []Child) {
+		for i := range children {
+			child := &children[i]
+
+			if err := validateType(child.Type, types); err != nil {
+				errs = append(errs, fmt.Errorf("child '%s' of template '%s': %w", child.Title, template.Name, err))
+				continue
+			}
+
+			if child.Link != "" && child.Link != ChildLink {
+				if child.Link != TestedByLink {
+					errs = append(errs, fmt.Errorf("%w: link '%s' of child '%s' of template '%s' should be one of %v",
+						ErrInvalidChildLink, child.Link, child.Title, template.Name, AvailableChildLinks))
+				}
+
+				// children that aren't linked as children can be of any type
+				validate(child.Type, child.Children)
+				continue
+			}
+
+			childLevel, childRanked := HierarchyLevels[child.Type]
+			parentLevel, parentRanked := HierarchyLevels[parentType]
+
+			if childRanked && parentRanked && childLevel >= parentLevel {
+				errs = append(errs, fmt.Errorf("%w: %s '%s' cannot be a child of a %s in template '%s'",
+					ErrInvalidChildType, child.Type, child.Title, parentType, template.Name))
+			}
+
+			validate(child.Type, child.Children)
+		}
+	}
+	validate(template.Type, template.Children)
+
+	return errors.Join(errs...)
+}
+
+// dependencyCycle returns the titles of the tasks along the first cycle found
+// in the dependencies, starting and ending with the same task, where each task
+// depends on the next one. Returns nil if there is no cycle.
+func dependencyCycle(tasks []Task, dependencies [][]int) []string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := make([]int, len(tasks))
+	path := []int{}
+
+	var visit func(current int) []string
+	visit = func(current int) []string {
+		state[current] = inProgress
+		path = append(path, current)
+
+		for _, predecessor := range dependencies[current] {
+			switch state[predecessor] {
+			case inProgress:
+				start := 0
+				for path[start] != predecessor {
+					start++
+				}
+
+				chain := []string{}
+				for _, task := range path[start:] {
+					chain = append(chain, tasks[task].Title)
+				}
+
+				return append(chain, tasks[predecessor].Title)
+			case unvisited:
+				if chain := visit(predecessor); chain != nil {
+					return chain
+				}
+			}
+		}
+
+		state[current] = done
+		path = path[:len(path)-1]
+
+		return nil
+	}
+
+	for i := range tasks {
+		if state[i] == unvisited {
+			if chain := visit(i); chain != nil {
+				return chain
+			}
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,278 @@
+package crusado
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDependencyCycle(t *testing.T) {
+	tasks := []Task{{Title: "A"}, {Title: "B"}, {Title: "C"}, {Title: "D"}}
+
+	tests := []struct {
+		name         string
+		dependencies [][]int
+		expected     []string
+	}{
+		{
+			name:         "no dependencies",
+			dependencies: [][]int{nil, nil, nil, nil},
+			expected:     nil,
+		},
+		{
+			name:         "diamond",
+			dependencies: [][]int{nil, {0}, {0}, {1, 2}},
+			expected:     nil,
+		},
+		{
+			name:         "self dependency",
+			dependencies: [][]int{nil, {1}, nil, nil},
+			expected:     []string{"B", "B"},
+		},
+		{
+			name:         "two tasks",
+			dependencies: [][]int{{1}, {0}, nil, nil},
+			expected:     []string{"A", "B", "A"},
+		},
+		{
+			name:         "cycle behind a task outside of it",
+			dependencies: [][]int{{1}, {2}, {3}, {1}},
+			expected:     []string{"B", "C", "D", "B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if chain := dependencyCycle(tasks, tt.dependencies); !reflect.DeepEqual(chain, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, chain)
+			}
+		})
+	}
+}
+
+func TestValidateExtends(t *testing.T) {
+	tests := []struct {
+		name         string
+		templates    []Template
+		expectedErrs []error
+	}{
+		{
+			name: "chain",
+			templates: []Template{
+				{Meta: Meta{Name: "leaf", Extends: "middle"}},
+				{Meta: Meta{Name: "middle", Extends: "base"}},
+				{Meta: Meta{Name: "base"}},
+			},
+		},
+		{
+			name: "unknown parent",
+			templates: []Template{
+				{Meta: Meta{Name: "leaf", Extends: "base"}},
+			},
+			expectedErrs: []error{ErrUnknownParent},
+		},
+		{
+			name: "self",
+			templates: []Template{
+				{Meta: Meta{Name: "base", Extends: "base"}},
+			},
+			expectedErrs: []error{ErrInheritanceCycle},
+		},
+		{
+			name: "self in namespace",
+			templates: []Template{
+				{Namespace: "team", Meta: Meta{Name: "team/base", Extends: "base"}},
+			},
+			expectedErrs: []error{ErrInheritanceCycle},
+		},
+		{
+			name: "same name across namespaces",
+			templates: []Template{
+				{Namespace: "team", Meta: Meta{Name: "team/base", Extends: "base"}},
+				{Meta: Meta{Name: "base"}},
+			},
+		},
+		{
+			name: "cycle",
+			templates: []Template{
+				{Meta: Meta{Name: "a", Extends: "b"}},
+				{Meta: Meta{Name: "b", Extends: "c"}},
+				{Meta: Meta{Name: "c", Extends: "a"}},
+			},
+			expectedErrs: []error{ErrInheritanceCycle},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExtends(tt.templates)
+
+			if len(tt.expectedErrs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expectedErr := range tt.expectedErrs {
+				if !errors.Is(err, expectedErr) {
+					t.Errorf("expected error %v, got %v", expectedErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestInheritanceCycle(t *testing.T) {
+	// c extends the cycle of a and b without being part of it
+	templates := []Template{
+		{Meta: Meta{Name: "a", Extends: "b"}},
+		{Meta: Meta{Name: "b", Extends: "a"}},
+		{Meta: Meta{Name: "c", Extends: "a"}},
+		{Meta: Meta{Name: "d", Extends: "c"}},
+		{Meta: Meta{Name: "e"}},
+	}
+
+	tests := []struct {
+		start    int
+		expected []string
+	}{
+		{start: 0, expected: []string{"a", "b", "a"}},
+		{start: 1, expected: []string{"b", "a", "b"}},
+		{start: 2, expected: nil},
+		{start: 3, expected: nil},
+		{start: 4, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(templates[tt.start].Name, func(t *testing.T) {
+			if chain := inheritanceCycle(templates, tt.start); !reflect.DeepEqual(chain, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, chain)
+			}
+		})
+	}
+}
+
+func TestValidateChildren(t *testing.T) {
+	types := append([]Type{}, AvailableTypes...)
+	types = append(types, "Risk")
+
+	tests := []struct {
+		name         string
+		templateType Type
+		children     []Child
+		expectedErrs []error
+	}{
+		{
+			name:         "no children",
+			templateType: UserStoryType,
+		},
+		{
+			name:         "epic, feature, story",
+			templateType: EpicType,
+			children: []Child{
+				{Type: FeatureType, Children: []Child{{Type: UserStoryType}, {Type: BugType}}},
+				{Type: UserStoryType},
+			},
+		},
+		{
+			name:         "unranked children of any type",
+			templateType: UserStoryType,
+			children:     []Child{{Type: TestCaseType}, {Type: "Risk", Children: []Child{{Type: EpicType}}}},
+		},
+		{
+			name:         "test case tests its parent",
+			templateType: UserStoryType,
+			children:     []Child{{Type: TestCaseType, Link: TestedByLink}},
+		},
+		{
+			name:         "children not linked as children can be of any type",
+			templateType: UserStoryType,
+			children:     []Child{{Type: FeatureType, Link: TestedByLink, Children: []Child{{Type: UserStoryType}}}},
+		},
+		{
+			name:         "same level",
+			templateType: UserStoryType,
+			children:     []Child{{Type: BugType}},
+			expectedErrs: []error{ErrInvalidChildType},
+		},
+		{
+			name:         "higher level",
+			templateType: FeatureType,
+			children:     []Child{{Type: EpicType}},
+			expectedErrs: []error{ErrInvalidChildType},
+		},
+		{
+			name:         "nested child on a higher level",
+			templateType: EpicType,
+			children:     []Child{{Type: FeatureType, Children: []Child{{Type: UserStoryType, Children: []Child{{Type: FeatureType}}}}}},
+			expectedErrs: []error{ErrInvalidChildType},
+		},
+		{
+			name:         "unknown type",
+			templateType: FeatureType,
+			children:     []Child{{Type: "Story"}},
+			expectedErrs: []error{ErrInvalidType},
+		},
+		{
+			name:         "missing type",
+			templateType: FeatureType,
+			children:     []Child{{Type: UserStoryType, Children: []Child{{Title: "Untyped"}}}},
+			expectedErrs: []error{ErrTypeNotSet},
+		},
+		{
+			name:         "unknown link",
+			templateType: UserStoryType,
+			children:     []Child{{Type: TestCaseType, Link: "relates"}},
+			expectedErrs: []error{ErrInvalidChildLink},
+		},
+		{
+			name:         "all errors",
+			templateType: FeatureType,
+			children:     []Child{{Type: EpicType}, {Type: "Story"}, {Type: TestCaseType, Link: "relates"}},
+			expectedErrs: []error{ErrInvalidChildType, ErrInvalidType, ErrInvalidChildLink},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &Template{Meta: Meta{Name: "template", Type: tt.templateType, Children: tt.children}}
+
+			err := ValidateChildren(template, types)
+
+			if len(tt.expectedErrs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expectedErr := range tt.expectedErrs {
+				if !errors.Is(err, expectedErr) {
+					t.Errorf("expected error %v, got %v", expectedErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTemplateTypes(t *testing.T) {
+	service := &Service{CustomTypes: []Type{"Risk"}}
+
+	tests := []struct {
+		name         string
+		templateType Type
+		children     []Child
+		expectedErr  error
+	}{
+		{name: "available type", templateType: UserStoryType},
+		{name: "custom type", templateType: "Risk"},
+		{name: "custom type of child", templateType: UserStoryType, children: []Child{{Title: "Risk", Type: "Risk"}}},
+		{name: "unknown type", templateType: "Story", expectedErr: ErrInvalidType},
+		{name: "unknown type of child", templateType: FeatureType, children: []Child{{Title: "Story", Type: "Story"}}, expectedErr: ErrInvalidType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &Template{Meta: Meta{Name: "template", Type: tt.templateType, Children: tt.children}}
+
+			if err := ValidateTemplate(template, service.Types()); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
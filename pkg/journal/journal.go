@@ -0,0 +1,245 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+	"github.com/simonkienzler/crusado/pkg/workitems"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v2"
+)
+
+// Status is the state an apply is in.
+type Status string
+
+const (
+	RunningStatus   = Status("running")
+	FailedStatus    = Status("failed")
+	CompletedStatus = Status("completed")
+
+	// runIDFormat is the time format run IDs start with
+	runIDFormat = "20060102-150405"
+
+	// runIDSuffixLength is the number of random characters appended to run
+	// IDs, so runs started within the same second don't share their journal
+	runIDSuffixLength = 8
+)
+
+var (
+	ErrRunNotFound      = errors.New("no journal found for run")
+	ErrRunCompleted     = errors.New("run has already been completed")
+	ErrTemplateChanged  = errors.New("template doesn't match the journal of the run anymore")
+	ErrNoJournalDirSet  = errors.New("no journal directory set")
+	errInvalidEntryPath = errors.New("invalid path")
+)
+
+// Journal records an apply, so it can be resumed if it fails halfway through.
+// It holds everything needed to render the same template again and the work
+// items that have already been created.
+type Journal struct {
+	RunID     string    `yaml:"runId"`
+	Status    Status    `yaml:"status"`
+	StartedAt time.Time `yaml:"startedAt"`
+
+	Template string `yaml:"template"`
+
+	// Parameters holds the values of all parameters, including the ones the
+	// user was prompted for
+	Parameters map[string]string `yaml:"parameters,omitempty"`
+
+	// Fields holds the field overrides in the format of the --field flag
+	Fields   []string `yaml:"fields,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+	AssignTo string   `yaml:"assignTo,omitempty"`
+
+	Team          string `yaml:"team,omitempty"`
+	AreaPath      string `yaml:"areaPath"`
+	IterationPath string `yaml:"iterationPath"`
+	Parent        int    `yaml:"parent,omitempty"`
+
+	// Items holds the work items that have been created, in order of
+	// creation
+	Items []Entry `yaml:"items,omitempty"`
+}
+
+// Entry is a work item created by an apply.
+type Entry struct {
+	// Path is the position of the item in the tree, the indexes of the
+	// children leading to it joined by slashes. The top-level item has the
+	// path "0"
+	Path  string       `yaml:"path"`
+	Type  crusado.Type `yaml:"type"`
+	Title string       `yaml:"title"`
+	ID    int          `yaml:"id"`
+
+	// Linked holds the indexes of the predecessors the work item has been
+	// linked to, see workitems.Item.Linked
+	Linked []int `yaml:"linked,omitempty"`
+}
+
+// Store reads and writes journals as YAML files in a directory, one per run.
+type Store struct {
+	Directory string
+}
+
+// New returns the journal of a new run of the given template, starting now.
+func New(template string) *Journal {
+	now := time.Now()
+
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:runIDSuffixLength]
+
+	return &Journal{
+		RunID:     now.Format(runIDFormat) + "-" + suffix,
+		Status:    RunningStatus,
+		StartedAt: now,
+		Template:  template,
+	}
+}
+
+// Load reads the journal of the run with the given ID.
+func (s *Store) Load(runID string) (*Journal, error) {
+	if s.Directory == "" {
+		return nil, ErrNoJournalDirSet
+	}
+
+	content, err := os.ReadFile(s.path(runID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{}
+	if err := yaml.Unmarshal(content, journal); err != nil {
+		return nil, fmt.Errorf("could not parse journal of run %s: %w", runID, err)
+	}
+
+	return journal, nil
+}
+
+// Save writes the journal, replacing the one previously saved for its run.
+func (s *Store) Save(journal *Journal) error {
+	if s.Directory == "" {
+		return ErrNoJournalDirSet
+	}
+
+	content, err := yaml.Marshal(journal)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.Directory, 0o700); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a crash never leaves a truncated
+	// journal behind
+	tmp := s.path(journal.RunID) + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path(journal.RunID))
+}
+
+func (s *Store) path(runID string) string {
+	return filepath.Join(s.Directory, filepath.Base(runID)+".yaml")
+}
+
+// Record replaces the entries of the journal with the work items of the tree
+// that have been created so far.
+func (j *Journal) Record(tree *workitems.Item) {
+	j.Items = nil
+
+	walk(tree, "0", func(item *workitems.Item, path string) {
+		if item.WorkItem == nil || item.WorkItem.Id == nil {
+			return
+		}
+
+		j.Items = append(j.Items, Entry{
+			Path:   path,
+			Type:   item.Type,
+			Title:  item.Title,
+			ID:     *item.WorkItem.Id,
+			Linked: append([]int{}, item.Linked...),
+		})
+	})
+}
+
+// Resumable returns whether resuming the run makes sense, i.e. it failed and
+// work items it created are left, see Record. Resuming a run without work items
+// would create all of them again, just like a new run.
+func (j *Journal) Resumable() bool {
+	return j.Status == FailedStatus && len(j.Items) > 0
+}
+
+// Restore sets the work items of all items of the tree that have been created
+// by the run, so they are neither created again nor rolled back. Returns an
+// error if an entry doesn't match the item at its position, e.g. because the
+// template has been changed in the meantime.
+func (j *Journal) Restore(ctx context.Context, service *workitems.Service, tree *workitems.Item) error {
+	errs := []error{}
+
+	for _, entry := range j.Items {
+		item, err := find(tree, entry.Path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s '%s' (#%d): %w", ErrTemplateChanged, entry.Type, entry.Title, entry.ID, err))
+			continue
+		}
+
+		if item.Type != entry.Type || item.Title != entry.Title {
+			errs = append(errs, fmt.Errorf("%w: expected %s '%s' (#%d), found %s '%s'", ErrTemplateChanged, entry.Type, entry.Title, entry.ID, item.Type, item.Title))
+			continue
+		}
+
+		item.WorkItem, err = service.GetWorkItem(ctx, entry.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not get %s '%s' (#%d): %w", entry.Type, entry.Title, entry.ID, err))
+			continue
+		}
+
+		item.Linked = append([]int{}, entry.Linked...)
+		item.Restored = true
+	}
+
+	return errors.Join(errs...)
+}
+
+// walk calls fn for the item and everything underneath it, top-down, with the
+// path of each item, see Entry.Path.
+func walk(item *workitems.Item, path string, fn func(item *workitems.Item, path string)) {
+	fn(item, path)
+
+	for i, child := range item.Children {
+		walk(child, path+"/"+strconv.Itoa(i), fn)
+	}
+}
+
+// find returns the item of the tree at the given path.
+func find(tree *workitems.Item, path string) (*workitems.Item, error) {
+	indexes := strings.Split(path, "/")
+	if indexes[0] != "0" {
+		return nil, fmt.Errorf("%w '%s'", errInvalidEntryPath, path)
+	}
+
+	item := tree
+	for _, index := range indexes[1:] {
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= len(item.Children) {
+			return nil, fmt.Errorf("%w '%s'", errInvalidEntryPath, path)
+		}
+
+		item = item.Children[i]
+	}
+
+	return item, nil
+}
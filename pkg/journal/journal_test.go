@@ -0,0 +1,216 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+	"github.com/simonkienzler/crusado/pkg/workitems"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+func testTree() *workitems.Item {
+	return &workitems.Item{
+		Type:  crusado.UserStoryType,
+		Title: "Story",
+		Children: []*workitems.Item{
+			{Type: crusado.TaskType, Title: "Build"},
+			{Type: crusado.TaskType, Title: "Deploy", DependsOn: []int{0}},
+			{
+				Type:  crusado.TestCaseType,
+				Title: "Test",
+				Children: []*workitems.Item{
+					{Type: crusado.TaskType, Title: "Automate"},
+				},
+			},
+		},
+	}
+}
+
+func TestFind(t *testing.T) {
+	tree := testTree()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "0", expected: "Story"},
+		{path: "0/1", expected: "Deploy"},
+		{path: "0/2/0", expected: "Automate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			item, err := find(tree, tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if item.Title != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, item.Title)
+			}
+		})
+	}
+}
+
+func TestFindInvalidPath(t *testing.T) {
+	tree := testTree()
+
+	for _, path := range []string{"", "1", "0/3", "0/-1", "0/0/0", "0/a", "0//1"} {
+		t.Run(path, func(t *testing.T) {
+			if _, err := find(tree, path); !errors.Is(err, errInvalidEntryPath) {
+				t.Errorf("expected error %v, got %v", errInvalidEntryPath, err)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	tree := testTree()
+
+	id := func(id int) *workitemtracking.WorkItem {
+		return &workitemtracking.WorkItem{Id: &id}
+	}
+
+	tree.WorkItem = id(1)
+	tree.Children[0].WorkItem = id(2)
+	tree.Children[1].WorkItem = id(3)
+	tree.Children[1].Linked = []int{0}
+	tree.Children[2].WorkItem = id(4)
+
+	journal := &Journal{Items: []Entry{{Path: "0", ID: 42}}}
+	journal.Record(tree)
+
+	expected := []Entry{
+		{Path: "0", Type: crusado.UserStoryType, Title: "Story", ID: 1, Linked: []int{}},
+		{Path: "0/0", Type: crusado.TaskType, Title: "Build", ID: 2, Linked: []int{}},
+		{Path: "0/1", Type: crusado.TaskType, Title: "Deploy", ID: 3, Linked: []int{0}},
+		{Path: "0/2", Type: crusado.TestCaseType, Title: "Test", ID: 4, Linked: []int{}},
+	}
+
+	if !reflect.DeepEqual(journal.Items, expected) {
+		t.Errorf("expected %+v, got %+v", expected, journal.Items)
+	}
+
+	for _, entry := range journal.Items {
+		item, err := find(tree, entry.Path)
+		if err != nil || item.Title != entry.Title {
+			t.Errorf("entry %+v doesn't point to its item: %v", entry, err)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := &Store{Directory: t.TempDir()}
+
+	journal := New("release")
+	journal.Parameters = map[string]string{"version": "1.2.3"}
+	journal.Items = []Entry{{Path: "0", Type: crusado.UserStoryType, Title: "Release 1.2.3", ID: 1}}
+
+	if err := store.Save(journal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := store.Load(journal.RunID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if loaded.RunID != journal.RunID || loaded.Status != RunningStatus || !reflect.DeepEqual(loaded.Items, journal.Items) ||
+		!reflect.DeepEqual(loaded.Parameters, journal.Parameters) {
+		t.Errorf("expected %+v, got %+v", journal, loaded)
+	}
+
+	if _, err := store.Load("unknown"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("expected error %v, got %v", ErrRunNotFound, err)
+	}
+}
+
+func TestNewRunIDsAreUnique(t *testing.T) {
+	if first, second := New("release"), New("release"); first.RunID == second.RunID {
+		t.Errorf("expected different run IDs, got '%s' twice", first.RunID)
+	}
+}
+
+// deletingClient deletes all work items except the failing ones.
+type deletingClient struct {
+	workitemtracking.Client
+	failing map[int]bool
+}
+
+func (c *deletingClient) DeleteWorkItem(_ context.Context, args workitemtracking.DeleteWorkItemArgs) (*workitemtracking.WorkItemDelete, error) {
+	if c.failing[*args.Id] {
+		return nil, errors.New("forbidden")
+	}
+
+	return &workitemtracking.WorkItemDelete{Id: args.Id}, nil
+}
+
+func TestResumableAfterRollback(t *testing.T) {
+	tests := []struct {
+		name     string
+		restored bool
+		failing  map[int]bool
+		rollback bool
+		expected bool
+	}{
+		{name: "complete rollback", rollback: true, expected: false},
+		{name: "no rollback", rollback: false, expected: true},
+		{name: "partial rollback", rollback: true, failing: map[int]bool{2: true}, expected: true},
+		{name: "complete rollback of a resumed run", rollback: true, restored: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := testTree()
+			tree.Children = tree.Children[:2]
+
+			id := func(id int) *workitemtracking.WorkItem {
+				return &workitemtracking.WorkItem{Id: &id}
+			}
+
+			// creating the second task failed
+			tree.WorkItem = id(1)
+			tree.Restored = tt.restored
+			tree.Children[0].WorkItem = id(2)
+
+			if tt.rollback {
+				service := &workitems.Service{WorkitemClient: &deletingClient{failing: tt.failing}}
+				_ = service.Rollback(context.Background(), tree, workitems.DeleteRollback, nil)
+			}
+
+			journal := New("release")
+			journal.Status = FailedStatus
+			journal.Record(tree)
+
+			if resumable := journal.Resumable(); resumable != tt.expected {
+				t.Errorf("expected %t, got %t for the entries %+v", tt.expected, resumable, journal.Items)
+			}
+		})
+	}
+}
+
+func TestResumable(t *testing.T) {
+	entries := []Entry{{Path: "0", ID: 1}}
+
+	tests := []struct {
+		status   Status
+		items    []Entry
+		expected bool
+	}{
+		{status: FailedStatus, items: entries, expected: true},
+		{status: FailedStatus, items: nil, expected: false},
+		{status: RunningStatus, items: entries, expected: false},
+		{status: CompletedStatus, items: entries, expected: false},
+	}
+
+	for _, tt := range tests {
+		journal := &Journal{Status: tt.status, Items: tt.items}
+		if resumable := journal.Resumable(); resumable != tt.expected {
+			t.Errorf("%s run with %d entries: expected %t, got %t", tt.status, len(tt.items), tt.expected, resumable)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// TeamDefaultAreaPath is resolved to the default area path configured for the
+// team.
+const TeamDefaultAreaPath = "@team"
+
+var (
+	ErrAreaPathNotFound      = errors.New("area path does not exist in project")
+	ErrTeamDefaultAreaNotSet = errors.New("team doesn't have a default area path")
+)
+
+// SelectAreaPath returns the area path work items are created in. The area path
+// of the template overrides the configured one, the one given when applying the
+// template overrides both. Empty area paths don't override anything.
+func SelectAreaPath(configured, template, override string) string {
+	areaPath := configured
+	if template != "" {
+		areaPath = template
+	}
+	if override != "" {
+		areaPath = override
+	}
+
+	return areaPath
+}
+
+// ResolveAreaPath returns the full area path for the given one, after making
+// sure it exists in the project. Area paths can be given relative to the
+// project, e.g. "Team A\Backend" for "Project\Team A\Backend". The
+// TeamDefaultAreaPath is resolved to the team's default area path.
+func (s *Service) ResolveAreaPath(ctx context.Context, areaPath string) (string, error) {
+	if areaPath == "" || areaPath == s.ProjectName {
+		return s.ProjectName, nil
+	}
+
+	if strings.EqualFold(areaPath, TeamDefaultAreaPath) {
+		return getTeamDefaultAreaPath(ctx, s.WorkClient, s.ProjectName, s.TeamName)
+	}
+
+	areaPath = strings.Trim(areaPath, "\\")
+	if !strings.HasPrefix(areaPath, s.ProjectName+"\\") {
+		areaPath = s.ProjectName + "\\" + areaPath
+	}
+
+	if err := validateAreaPath(ctx, s.WorkitemClient, s.ProjectName, areaPath); err != nil {
+		return "", err
+	}
+
+	return areaPath, nil
+}
+
+// validateAreaPath checks that a classification node exists for the given
+// area path, which has to start with the project name.
+func validateAreaPath(ctx context.Context, client workitemtracking.Client, project, areaPath string) error {
+	relativePath := strings.TrimPrefix(areaPath, project+"\\")
+
+	node, err := client.GetClassificationNode(ctx, workitemtracking.GetClassificationNodeArgs{
+		Project:        &project,
+		StructureGroup: &workitemtracking.TreeStructureGroupValues.Areas,
+		Path:           &relativePath,
+	})
+	if err != nil || node == nil {
+		return fmt.Errorf("%w: %s", ErrAreaPathNotFound, areaPath)
+	}
+
+	return nil
+}
+
+// getTeamDefaultAreaPath returns the area path that is configured as default
+// for the team. An empty team refers to the project's default team.
+func getTeamDefaultAreaPath(ctx context.Context, client work.Client, project, team string) (string, error) {
+	teamFieldValues, err := client.GetTeamFieldValues(ctx, work.GetTeamFieldValuesArgs{
+		Project: &project,
+		Team:    optionalStringPointer(team),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if teamFieldValues == nil || teamFieldValues.DefaultValue == nil || *teamFieldValues.DefaultValue == "" {
+		return "", ErrTeamDefaultAreaNotSet
+	}
+
+	return *teamFieldValues.DefaultValue, nil
+}
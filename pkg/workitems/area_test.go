@@ -0,0 +1,125 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// fakeAreaClient only knows the given area paths, relative to the project.
+type fakeAreaClient struct {
+	workitemtracking.Client
+
+	areaPaths map[string]bool
+}
+
+func (c *fakeAreaClient) GetClassificationNode(_ context.Context, args workitemtracking.GetClassificationNodeArgs,
+) (*workitemtracking.WorkItemClassificationNode, error) {
+	if !c.areaPaths[*args.Path] {
+		return nil, errors.New("not found")
+	}
+
+	return &workitemtracking.WorkItemClassificationNode{Name: args.Path}, nil
+}
+
+// fakeTeamClient returns the default area paths of the given teams, the one of
+// the empty team being the one of the project's default team.
+type fakeTeamClient struct {
+	work.Client
+
+	defaultAreaPaths map[string]string
+}
+
+func (c *fakeTeamClient) GetTeamFieldValues(_ context.Context, args work.GetTeamFieldValuesArgs) (*work.TeamFieldValues, error) {
+	team := ""
+	if args.Team != nil {
+		team = *args.Team
+	}
+
+	defaultAreaPath, exists := c.defaultAreaPaths[team]
+	if !exists {
+		return nil, errors.New("team not found")
+	}
+
+	return &work.TeamFieldValues{DefaultValue: &defaultAreaPath}, nil
+}
+
+func TestSelectAreaPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		template   string
+		override   string
+		expected   string
+	}{
+		{name: "none", expected: ""},
+		{name: "configured", configured: "Config", expected: "Config"},
+		{name: "template overrides config", configured: "Config", template: "Template", expected: "Template"},
+		{name: "flag overrides template", configured: "Config", template: "Template", override: "Flag", expected: "Flag"},
+		{name: "flag overrides config", configured: "Config", override: "Flag", expected: "Flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if areaPath := SelectAreaPath(tt.configured, tt.template, tt.override); areaPath != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, areaPath)
+			}
+		})
+	}
+}
+
+func TestResolveAreaPath(t *testing.T) {
+	service := &Service{
+		WorkitemClient: &fakeAreaClient{areaPaths: map[string]bool{"Team A": true, "Team A\\Backend": true}},
+		WorkClient:     &fakeTeamClient{defaultAreaPaths: map[string]string{"": "Project", "Team A": "Project\\Team A"}},
+		ProjectName:    "Project",
+	}
+
+	tests := []struct {
+		name        string
+		areaPath    string
+		team        string
+		expected    string
+		expectedErr error
+	}{
+		{name: "empty", areaPath: "", expected: "Project"},
+		{name: "project", areaPath: "Project", expected: "Project"},
+		{name: "relative", areaPath: "Team A\\Backend", expected: "Project\\Team A\\Backend"},
+		{name: "absolute", areaPath: "Project\\Team A\\Backend", expected: "Project\\Team A\\Backend"},
+		{name: "surrounding backslashes", areaPath: "\\Team A\\", expected: "Project\\Team A"},
+		{name: "unknown", areaPath: "Team B", expectedErr: ErrAreaPathNotFound},
+		{name: "unknown absolute", areaPath: "Project\\Team B", expectedErr: ErrAreaPathNotFound},
+		{name: "default team", areaPath: TeamDefaultAreaPath, expected: "Project"},
+		{name: "team ignoring case", areaPath: "@Team", team: "Team A", expected: "Project\\Team A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.TeamName = tt.team
+
+			areaPath, err := service.ResolveAreaPath(context.Background(), tt.areaPath)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if areaPath != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, areaPath)
+			}
+		})
+	}
+}
+
+func TestResolveAreaPathOfTeamWithoutDefault(t *testing.T) {
+	service := &Service{
+		WorkClient:  &fakeTeamClient{defaultAreaPaths: map[string]string{"Team A": ""}},
+		ProjectName: "Project",
+		TeamName:    "Team A",
+	}
+
+	if _, err := service.ResolveAreaPath(context.Background(), TeamDefaultAreaPath); !errors.Is(err, ErrTeamDefaultAreaNotSet) {
+		t.Errorf("expected %v, got %v", ErrTeamDefaultAreaNotSet, err)
+	}
+}
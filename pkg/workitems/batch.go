@@ -0,0 +1,309 @@
+package workitems
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+const (
+	// batchAPIVersion is the version of the work item batch API, which isn't
+	// part of the generated clients
+	batchAPIVersion = "5.1"
+
+	// maxBatchSize is the maximum number of requests Azure DevOps accepts in
+	// a single batch request
+	maxBatchSize = 200
+
+	// jsonPatchMediaType is the content type of the requests in a batch
+	jsonPatchMediaType = "application/json-patch+json"
+)
+
+var (
+	ErrBatchUnavailable = errors.New("batch requests are unavailable")
+	ErrBatchFailed      = errors.New("batch request failed, its work items might have been created nonetheless")
+	errBatchIncomplete  = errors.New("batch response doesn't contain a result for every request")
+)
+
+// batchRequest is a single request of a batch request.
+type batchRequest struct {
+	Method  string                      `json:"method"`
+	URI     string                      `json:"uri"`
+	Headers map[string]string           `json:"headers"`
+	Body    []webapi.JsonPatchOperation `json:"body"`
+}
+
+// batchResponse holds the results of the requests of a batch request, in the
+// order of the requests. The body of each result is a JSON document itself.
+type batchResponse struct {
+	Count int `json:"count"`
+	Value []struct {
+		Code int    `json:"code"`
+		Body string `json:"body"`
+	} `json:"value"`
+}
+
+// batchItem is an item that is created by a batch request.
+type batchItem struct {
+	item   *Item
+	parent *Item
+	depth  int
+}
+
+// createTreeBatched creates the item and everything underneath it like
+// createTree does, but with as few batch requests as possible. Items that are
+// created in the same batch as their parent reference it by a temporary,
+// negative ID. The dependencies are linked in batches once all items exist.
+// Returns an error wrapping ErrBatchUnavailable if the batch endpoint isn't
+// supported, in which case the items that haven't been created can still be
+// created one by one.
+func (s *Service) createTreeBatched(ctx context.Context, root *Item, parent *workitemtracking.WorkItem, created func(item *Item, depth int)) error {
+	if s.Connection == nil {
+		return fmt.Errorf("%w: no connection", ErrBatchUnavailable)
+	}
+
+	// collect the items in the order createTree would create them, so
+	// parents are always created before their children
+	items := []batchItem{}
+
+	var collect func(item, itemParent *Item, depth int)
+	collect = func(item, itemParent *Item, depth int) {
+		if item.WorkItem == nil {
+			items = append(items, batchItem{item: item, parent: itemParent, depth: depth})
+		}
+
+		for _, child := range item.Children {
+			collect(child, item, depth+1)
+		}
+	}
+	collect(root, nil, 0)
+
+	for start := 0; start < len(items); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		if err := s.createBatch(ctx, items[start:end], parent, created); err != nil {
+			return err
+		}
+	}
+
+	return s.linkDependenciesBatched(ctx, root)
+}
+
+// createBatch creates the given items with a single batch request. Parents that
+// are part of the same batch are referenced by their temporary ID, which is the
+// negated position of the parent in the batch, starting at 1.
+func (s *Service) createBatch(ctx context.Context, items []batchItem, parent *workitemtracking.WorkItem, created func(item *Item, depth int)) error {
+	tempIDs := map[*Item]int{}
+	requests := make([]batchRequest, 0, len(items))
+
+	for i, b := range items {
+		document, workItemType, err := s.buildWorkItemJSONPatchDocument(b.item.Title, b.item.Description, b.item.Type, b.item.Fields, b.item.Tags)
+		if err != nil {
+			return fmt.Errorf("could not create %s '%s': %w", s.WorkItemTypeName(b.item.Type), b.item.Title, err)
+		}
+
+		tempIDs[b.item] = -(i + 1)
+		document = append(document, buildJSONPatchOperation(addOp, "/id", tempIDs[b.item]))
+
+		linkType := b.item.LinkType
+		if linkType == "" || b.item.Type == crusado.TaskType {
+			linkType = ParentLinkType
+		}
+
+		switch {
+		case b.parent == nil && parent != nil:
+			document = append(document, buildRelationOperation(parent, linkType))
+		case b.parent != nil && b.parent.WorkItem != nil:
+			document = append(document, buildRelationOperation(b.parent.WorkItem, linkType))
+		case b.parent != nil:
+			tempParent := &workitemtracking.WorkItem{Url: stringPointer(s.workItemURL(tempIDs[b.parent]))}
+			document = append(document, buildRelationOperation(tempParent, linkType))
+		}
+
+		requests = append(requests, s.buildBatchRequest(http.MethodPatch, "$"+workItemType.Name, document))
+	}
+
+	results, err := s.sendBatch(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	// the requests of a batch don't depend on each other's success, so all
+	// created items are recorded, even after an item couldn't be created
+	errs := []error{}
+
+	for i, b := range items {
+		workItem := &workitemtracking.WorkItem{}
+		if err := decodeBatchResult(results.Value[i].Code, results.Value[i].Body, workItem); err != nil {
+			errs = append(errs, fmt.Errorf("could not create %s '%s': %w", s.WorkItemTypeName(b.item.Type), b.item.Title, err))
+			continue
+		}
+
+		b.item.WorkItem = workItem
+
+		if created != nil {
+			created(b.item, b.depth)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// linkDependenciesBatched links all dependencies of the tree that aren't linked
+// yet, see LinkDependency, with as few batch requests as possible.
+func (s *Service) linkDependenciesBatched(ctx context.Context, root *Item) error {
+	type link struct {
+		predecessor int
+		successor   *Item
+		siblings    []*Item
+	}
+
+	links := []link{}
+	root.Walk(func(item *Item, _ int) {
+		for _, successor := range item.Children {
+			for _, predecessor := range successor.DependsOn {
+				if !successor.isLinkedTo(predecessor) {
+					links = append(links, link{predecessor: predecessor, successor: successor, siblings: item.Children})
+				}
+			}
+		}
+	})
+
+	for start := 0; start < len(links); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(links) {
+			end = len(links)
+		}
+
+		requests := []batchRequest{}
+		for _, l := range links[start:end] {
+			predecessor := l.siblings[l.predecessor].WorkItem
+			if predecessor == nil || l.successor.WorkItem == nil || l.successor.WorkItem.Id == nil {
+				return ErrCouldNotLinkDependency
+			}
+
+			document := []webapi.JsonPatchOperation{buildRelationOperation(predecessor, PredecessorLinkType)}
+			requests = append(requests, s.buildBatchRequest(http.MethodPatch, strconv.Itoa(*l.successor.WorkItem.Id), document))
+		}
+
+		results, err := s.sendBatch(ctx, requests)
+		if err != nil {
+			return err
+		}
+
+		errs := []error{}
+
+		for i, l := range links[start:end] {
+			if err := decodeBatchResult(results.Value[i].Code, results.Value[i].Body, nil); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %w", ErrCouldNotLinkDependency, err))
+				continue
+			}
+
+			l.successor.Linked = append(l.successor.Linked, l.predecessor)
+		}
+
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildBatchRequest returns a request to the work item with the given ID, or
+// of the given type prefixed with $ for new work items.
+func (s *Service) buildBatchRequest(method, workItem string, document []webapi.JsonPatchOperation) batchRequest {
+	return batchRequest{
+		Method: method,
+		URI: fmt.Sprintf("/%s/_apis/wit/workitems/%s?api-version=%s",
+			url.PathEscape(s.ProjectName), url.PathEscape(workItem), batchAPIVersion),
+		Headers: map[string]string{
+			"Content-Type": jsonPatchMediaType,
+		},
+		Body: document,
+	}
+}
+
+// workItemURL returns the API URL of the work item with the given ID, which
+// may be a temporary one.
+func (s *Service) workItemURL(id int) string {
+	return fmt.Sprintf("%s/_apis/wit/workItems/%d", s.Connection.BaseUrl, id)
+}
+
+// sendBatch sends the requests with a single batch request. The results of the
+// single requests have to be checked by the caller. Returns an error wrapping
+// ErrBatchUnavailable only if the batch request certainly wasn't processed,
+// i.e. it couldn't be sent or the endpoint doesn't exist. All other failures,
+// e.g. timeouts, wrap ErrBatchFailed, as the server might have created the
+// work items without us knowing about them.
+func (s *Service) sendBatch(ctx context.Context, requests []batchRequest) (*batchResponse, error) {
+	if s.batchSender != nil {
+		return s.batchSender(ctx, requests)
+	}
+
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.Connection.GetClientByUrl(s.Connection.BaseUrl)
+
+	request, err := client.CreateRequestMessage(ctx, http.MethodPost, s.Connection.BaseUrl+"/_apis/wit/$batch",
+		batchAPIVersion, bytes.NewReader(body), azuredevops.MediaTypeApplicationJson, azuredevops.MediaTypeApplicationJson, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBatchUnavailable, err)
+	}
+
+	response, err := client.SendRequest(request)
+	if err != nil {
+		if response != nil && (response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusMethodNotAllowed) {
+			return nil, fmt.Errorf("%w: %w", ErrBatchUnavailable, err)
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrBatchFailed, err)
+	}
+
+	results := &batchResponse{}
+	if err := client.UnmarshalBody(response, results); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBatchFailed, err)
+	}
+
+	if len(results.Value) != len(requests) {
+		return nil, fmt.Errorf("%w: %w", ErrBatchFailed, errBatchIncomplete)
+	}
+
+	return results, nil
+}
+
+// decodeBatchResult unmarshals the body of a successful result into v, which
+// may be nil. Returns the error message of unsuccessful results as error.
+func decodeBatchResult(code int, body string, v interface{}) error {
+	if code < 200 || code >= 300 {
+		wrapped := &azuredevops.WrappedError{}
+		if err := json.Unmarshal([]byte(body), wrapped); err != nil || wrapped.Message == nil {
+			return fmt.Errorf("request returned status %d", code)
+		}
+
+		return wrapped
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(body), v)
+}
@@ -0,0 +1,333 @@
+package workitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+const testBaseURL = "https://dev.azure.com/org"
+
+func testWorkItem(id int) *workitemtracking.WorkItem {
+	return &workitemtracking.WorkItem{Id: &id, Url: stringPointer(fmt.Sprintf("%s/_apis/wit/workItems/%d", testBaseURL, id))}
+}
+
+// fakeClient creates, updates and deletes work items without sending any
+// requests, recording the states set by updates. Work items with a failing ID
+// can't be updated or deleted.
+type fakeClient struct {
+	workitemtracking.Client
+
+	nextID  int
+	created []string
+	updated []int
+	deleted []int
+	states  map[int]string
+	failing map[int]bool
+}
+
+func (c *fakeClient) CreateWorkItem(_ context.Context, args workitemtracking.CreateWorkItemArgs) (*workitemtracking.WorkItem, error) {
+	c.nextID++
+	c.created = append(c.created, documentTitle(*args.Document))
+
+	return testWorkItem(c.nextID), nil
+}
+
+func (c *fakeClient) UpdateWorkItem(_ context.Context, args workitemtracking.UpdateWorkItemArgs) (*workitemtracking.WorkItem, error) {
+	if c.failing[*args.Id] {
+		return nil, errors.New("forbidden")
+	}
+
+	c.updated = append(c.updated, *args.Id)
+
+	for _, op := range *args.Document {
+		if *op.Path == "/fields/System.State" {
+			if c.states == nil {
+				c.states = map[int]string{}
+			}
+			c.states[*args.Id] = op.Value.(string)
+		}
+	}
+
+	return testWorkItem(*args.Id), nil
+}
+
+func (c *fakeClient) DeleteWorkItem(_ context.Context, args workitemtracking.DeleteWorkItemArgs) (*workitemtracking.WorkItemDelete, error) {
+	if c.failing[*args.Id] {
+		return nil, errors.New("forbidden")
+	}
+
+	c.deleted = append(c.deleted, *args.Id)
+
+	return &workitemtracking.WorkItemDelete{Id: args.Id}, nil
+}
+
+func documentTitle(document []webapi.JsonPatchOperation) string {
+	for _, op := range document {
+		if *op.Path == "/fields/System.Title" {
+			return op.Value.(string)
+		}
+	}
+
+	return ""
+}
+
+// testTree returns a story with two tasks, the second depending on the first,
+// and a test case with a task.
+func testTree() *Item {
+	return &Item{
+		Type:  crusado.UserStoryType,
+		Title: "Story",
+		Children: []*Item{
+			{Type: crusado.TaskType, Title: "Build"},
+			{Type: crusado.TaskType, Title: "Deploy", DependsOn: []int{0}},
+			{
+				Type:     crusado.TestCaseType,
+				Title:    "Test",
+				LinkType: TestsLinkType,
+				Children: []*Item{{Type: crusado.TaskType, Title: "Automate"}},
+			},
+		},
+	}
+}
+
+// fakeBatchSender answers batch requests like Azure DevOps does, creating work
+// items with IDs starting at 101 and linking dependencies. The requests of all
+// batches are recorded.
+type fakeBatchSender struct {
+	nextID  int
+	batches [][]batchRequest
+	failing map[string]bool
+}
+
+func (f *fakeBatchSender) send(_ context.Context, requests []batchRequest) (*batchResponse, error) {
+	f.batches = append(f.batches, requests)
+
+	response := &batchResponse{Count: len(requests)}
+
+	for _, request := range requests {
+		result := struct {
+			Code int    `json:"code"`
+			Body string `json:"body"`
+		}{Code: http.StatusOK, Body: "{}"}
+
+		title := documentTitle(request.Body)
+
+		switch {
+		case f.failing[title]:
+			result.Code = http.StatusBadRequest
+			result.Body = `{"message": "invalid field"}`
+		case strings.Contains(request.URI, "/$"):
+			f.nextID++
+			body, _ := json.Marshal(testWorkItem(100 + f.nextID))
+			result.Body = string(body)
+		}
+
+		response.Value = append(response.Value, result)
+	}
+
+	return response, nil
+}
+
+func operation(t *testing.T, request batchRequest, path string) interface{} {
+	t.Helper()
+
+	for _, op := range request.Body {
+		if *op.Path == path {
+			return op.Value
+		}
+	}
+
+	t.Fatalf("request %s doesn't contain an operation for %s", request.URI, path)
+
+	return nil
+}
+
+// assertTemporaryLinks asserts that the requests creating the test tree use
+// the negated positions in the batch as temporary IDs and link to the items
+// above them using these IDs.
+func assertTemporaryLinks(t *testing.T, service *Service, creates []batchRequest, parent *workitemtracking.WorkItem) {
+	t.Helper()
+
+	for i, request := range creates {
+		if id := operation(t, request, "/id"); id != -(i + 1) {
+			t.Errorf("expected temporary ID %d for request %d, got %v", -(i + 1), i, id)
+		}
+	}
+
+	expectedRelations := []struct {
+		url string
+		rel string
+	}{
+		{url: *parent.Url, rel: ParentLinkType},
+		{url: service.workItemURL(-1), rel: ParentLinkType},
+		{url: service.workItemURL(-1), rel: ParentLinkType},
+		{url: service.workItemURL(-1), rel: TestsLinkType},
+		{url: service.workItemURL(-4), rel: ParentLinkType},
+	}
+	for i, expected := range expectedRelations {
+		relation := operation(t, creates[i], "/relations/-").(workitemtracking.WorkItemRelation)
+		if *relation.Url != expected.url || *relation.Rel != expected.rel {
+			t.Errorf("expected request %d to link to %s as %s, got %s as %s", i, expected.url, expected.rel, *relation.Url, *relation.Rel)
+		}
+	}
+}
+
+func TestCreateTreeBatched(t *testing.T) {
+	sender := &fakeBatchSender{}
+	client := &fakeClient{}
+	service := &Service{
+		WorkitemClient: client,
+		ProjectName:    "Project",
+		Batch:          true,
+		Connection:     &azuredevops.Connection{BaseUrl: testBaseURL},
+		batchSender:    sender.send,
+	}
+
+	tree := testTree()
+	parent := testWorkItem(1)
+
+	created := []string{}
+	err := service.CreateTree(context.Background(), tree, parent, func(item *Item, depth int) {
+		created = append(created, fmt.Sprintf("%d %s", depth, item.Title))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sender.batches) != 2 || len(client.created) != 0 {
+		t.Fatalf("expected one batch to create and one to link, got %d batches and %d single requests", len(sender.batches), len(client.created))
+	}
+
+	expectedCreated := []string{"0 Story", "1 Build", "1 Deploy", "1 Test", "2 Automate"}
+	if !reflect.DeepEqual(created, expectedCreated) {
+		t.Errorf("expected %v to be created, got %v", expectedCreated, created)
+	}
+
+	assertTemporaryLinks(t, service, sender.batches[0], parent)
+
+	// the temporary IDs are replaced by the real ones
+	ids := []int{}
+	tree.Walk(func(item *Item, _ int) {
+		ids = append(ids, *item.WorkItem.Id)
+	})
+	if expected := []int{101, 102, 103, 104, 105}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected IDs %v, got %v", expected, ids)
+	}
+
+	links := sender.batches[1]
+	if len(links) != 1 || !strings.HasSuffix(strings.Split(links[0].URI, "?")[0], "/workitems/103") {
+		t.Fatalf("expected the dependency of Deploy (#103) to be linked, got %+v", links)
+	}
+
+	relation := operation(t, links[0], "/relations/-").(workitemtracking.WorkItemRelation)
+	if *relation.Url != *tree.Children[0].WorkItem.Url || *relation.Rel != PredecessorLinkType {
+		t.Errorf("expected a link to Build as predecessor, got %s as %s", *relation.Url, *relation.Rel)
+	}
+
+	if !reflect.DeepEqual(tree.Children[1].Linked, []int{0}) {
+		t.Errorf("expected the dependency to be recorded as linked, got %v", tree.Children[1].Linked)
+	}
+}
+
+func TestCreateTreeBatchedRecordsCreatedItemsOfFailedBatch(t *testing.T) {
+	sender := &fakeBatchSender{failing: map[string]bool{"Deploy": true}}
+	service := &Service{
+		WorkitemClient: &fakeClient{},
+		Batch:          true,
+		Connection:     &azuredevops.Connection{BaseUrl: testBaseURL},
+		batchSender:    sender.send,
+	}
+
+	tree := testTree()
+
+	err := service.CreateTree(context.Background(), tree, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid field") {
+		t.Fatalf("expected the error of the failed request, got %v", err)
+	}
+
+	if tree.Children[1].WorkItem != nil {
+		t.Errorf("expected Deploy not to be created, got #%d", *tree.Children[1].WorkItem.Id)
+	}
+
+	if tree.WorkItem == nil || tree.Children[0].WorkItem == nil || tree.Children[2].WorkItem == nil {
+		t.Errorf("expected all other items to be recorded as created")
+	}
+
+	if len(sender.batches) != 1 {
+		t.Errorf("expected dependencies not to be linked after a failure, got %d batches", len(sender.batches))
+	}
+}
+
+func TestCreateTreeFallsBackIfBatchUnavailable(t *testing.T) {
+	client := &fakeClient{}
+	service := &Service{
+		WorkitemClient: client,
+		Batch:          true,
+		Connection:     &azuredevops.Connection{BaseUrl: testBaseURL},
+		batchSender: func(context.Context, []batchRequest) (*batchResponse, error) {
+			return nil, fmt.Errorf("%w: 404 Not Found", ErrBatchUnavailable)
+		},
+	}
+
+	tree := testTree()
+
+	if err := service.CreateTree(context.Background(), tree, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"Story", "Build", "Deploy", "Test", "Automate"}; !reflect.DeepEqual(client.created, expected) {
+		t.Errorf("expected %v to be created one by one, got %v", expected, client.created)
+	}
+
+	if expected := []int{3}; !reflect.DeepEqual(client.updated, expected) {
+		t.Errorf("expected the dependency of #3 to be linked, got updates of %v", client.updated)
+	}
+}
+
+func TestCreateTreeDoesNotFallBackIfBatchFailed(t *testing.T) {
+	client := &fakeClient{}
+	service := &Service{
+		WorkitemClient: client,
+		Batch:          true,
+		Connection:     &azuredevops.Connection{BaseUrl: testBaseURL},
+		batchSender: func(context.Context, []batchRequest) (*batchResponse, error) {
+			return nil, fmt.Errorf("%w: timeout", ErrBatchFailed)
+		},
+	}
+
+	err := service.CreateTree(context.Background(), testTree(), nil, nil)
+	if !errors.Is(err, ErrBatchFailed) {
+		t.Errorf("expected error %v, got %v", ErrBatchFailed, err)
+	}
+
+	if len(client.created) != 0 {
+		t.Errorf("expected nothing to be created one by one, got %v", client.created)
+	}
+}
+
+func TestDecodeBatchResult(t *testing.T) {
+	workItem := &workitemtracking.WorkItem{}
+	if err := decodeBatchResult(http.StatusOK, `{"id": 42}`, workItem); err != nil || workItem.Id == nil || *workItem.Id != 42 {
+		t.Errorf("expected work item #42, got %+v (%v)", workItem, err)
+	}
+
+	if err := decodeBatchResult(http.StatusBadRequest, `{"message": "invalid field"}`, nil); err == nil || err.Error() != "invalid field" {
+		t.Errorf("expected the message of the result as error, got %v", err)
+	}
+
+	if err := decodeBatchResult(http.StatusInternalServerError, "", nil); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected the status of the result as error, got %v", err)
+	}
+}
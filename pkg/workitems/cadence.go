@@ -0,0 +1,151 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+var (
+	ErrNoCadence = errors.New("cannot derive a cadence, the team needs at least one iteration with start and finish date")
+)
+
+// trailingNumber matches names like "Sprint 42", so the next iteration can be
+// named "Sprint 43".
+var trailingNumber = regexp.MustCompile(`^(.*?)(\d+)$`)
+
+// CreateNextIterations creates the given number of iterations following the
+// last iteration of the team and adds them to the team's iteration settings.
+// Start date, length and name of the new iterations follow the cadence of the
+// last two iterations. New iterations are created next to the last iteration in
+// the project's iteration tree. If an iteration with the same name already
+// exists there, it is added to the team instead.
+func CreateNextIterations(ctx context.Context, witClient workitemtracking.Client, workClient work.Client,
+	project, team string, count int,
+) ([]work.TeamSettingsIteration, error) {
+	all, err := ListIterations(ctx, workClient, project, team)
+	if err != nil {
+		return nil, err
+	}
+
+	dated := []work.TeamSettingsIteration{}
+	for i := range all {
+		if iterationStart(&all[i]) != nil && iterationFinish(&all[i]) != nil {
+			dated = append(dated, all[i])
+		}
+	}
+
+	if len(dated) == 0 {
+		return nil, ErrNoCadence
+	}
+
+	last := dated[len(dated)-1]
+	start, finish := *iterationStart(&last), *iterationFinish(&last)
+	length := finish.Sub(start)
+
+	// without a previous iteration, assume the next one starts the day after
+	step := length + 24*time.Hour
+	if len(dated) > 1 {
+		step = start.Sub(*iterationStart(&dated[len(dated)-2]))
+	}
+
+	lastPath, err := iterationPath(&last)
+	if err != nil {
+		return nil, err
+	}
+	parentPath := lastPath[:strings.LastIndex(lastPath, "\\")+1]
+
+	name := ""
+	if last.Name != nil {
+		name = *last.Name
+	}
+
+	created := []work.TeamSettingsIteration{}
+
+	for i := 0; i < count; i++ {
+		start = start.Add(step)
+		name = nextIterationName(name)
+
+		node, err := createIterationNode(ctx, witClient, project, parentPath, name, start, start.Add(length))
+		if err != nil {
+			return created, err
+		}
+
+		iteration, err := workClient.PostTeamIteration(ctx, work.PostTeamIterationArgs{
+			Iteration: &work.TeamSettingsIteration{Id: node.Identifier},
+			Project:   &project,
+			Team:      optionalStringPointer(team),
+		})
+		if err != nil {
+			return created, fmt.Errorf("could not add iteration '%s' to team: %w", name, err)
+		}
+
+		created = append(created, *iteration)
+	}
+
+	return created, nil
+}
+
+// createIterationNode creates the iteration with the given name below the
+// given parent path, which includes the project. Returns the existing iteration
+// if there is one with that name.
+func createIterationNode(ctx context.Context, client workitemtracking.Client, project, parentPath, name string,
+	start, finish time.Time,
+) (*workitemtracking.WorkItemClassificationNode, error) {
+	// classification node paths are relative to the project
+	relativeParentPath := strings.Trim(strings.TrimPrefix(parentPath, project), "\\")
+	relativePath := strings.TrimPrefix(relativeParentPath+"\\"+name, "\\")
+
+	existing, err := client.GetClassificationNode(ctx, workitemtracking.GetClassificationNodeArgs{
+		Project:        &project,
+		StructureGroup: &workitemtracking.TreeStructureGroupValues.Iterations,
+		Path:           &relativePath,
+	})
+	if err == nil && existing != nil {
+		return existing, nil
+	}
+
+	attributes := map[string]interface{}{
+		"startDate":  start.Format(time.RFC3339),
+		"finishDate": finish.Format(time.RFC3339),
+	}
+
+	node, err := client.CreateOrUpdateClassificationNode(ctx, workitemtracking.CreateOrUpdateClassificationNodeArgs{
+		PostedNode: &workitemtracking.WorkItemClassificationNode{
+			Name:       &name,
+			Attributes: &attributes,
+		},
+		Project:        &project,
+		StructureGroup: &workitemtracking.TreeStructureGroupValues.Iterations,
+		Path:           optionalStringPointer(relativeParentPath),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create iteration '%s': %w", name, err)
+	}
+
+	return node, nil
+}
+
+// nextIterationName increments the number at the end of the name, keeping
+// leading zeros, e.g. "Sprint 09" becomes "Sprint 10". Appends a number if
+// there is none.
+func nextIterationName(name string) string {
+	matches := trailingNumber.FindStringSubmatch(name)
+	if matches == nil {
+		return strings.TrimSpace(name + " 2")
+	}
+
+	number, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return strings.TrimSpace(name + " 2")
+	}
+
+	return fmt.Sprintf("%s%0*d", matches[1], len(matches[2]), number+1)
+}
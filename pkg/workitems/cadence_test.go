@@ -0,0 +1,25 @@
+package workitems
+
+import "testing"
+
+func TestNextIterationName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Sprint 5", expected: "Sprint 6"},
+		{name: "Sprint 09", expected: "Sprint 10"},
+		{name: "Sprint 99", expected: "Sprint 100"},
+		{name: "2024-12", expected: "2024-13"},
+		{name: "42", expected: "43"},
+		{name: "Sprint", expected: "Sprint 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if name := nextIterationName(tt.name); name != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, name)
+			}
+		})
+	}
+}
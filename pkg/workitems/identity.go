@@ -0,0 +1,115 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/location"
+)
+
+// MeIdentity is resolved to the identity the personal access token belongs to.
+const MeIdentity = "@me"
+
+var (
+	ErrUnknownIdentity   = errors.New("no user found for identity")
+	ErrAmbiguousIdentity = errors.New("more than one user found for identity")
+)
+
+// ResolveIdentity looks up the user with the given email address or display
+// name and returns the value to use for identity fields like System.AssignedTo,
+// e.g. "Jane Doe <jane@example.com>". MeIdentity resolves to the user that
+// owns the personal access token.
+func (s *Service) ResolveIdentity(ctx context.Context, name string) (string, error) {
+	if strings.EqualFold(name, MeIdentity) {
+		connectionData, err := s.LocationClient.GetConnectionData(ctx, location.GetConnectionDataArgs{})
+		if err != nil {
+			return "", err
+		}
+
+		if connectionData.AuthenticatedUser == nil {
+			return "", fmt.Errorf("%w: %s", ErrUnknownIdentity, name)
+		}
+
+		return formatIdentity(connectionData.AuthenticatedUser), nil
+	}
+
+	identities, err := s.IdentityClient.ReadIdentities(ctx, identity.ReadIdentitiesArgs{
+		SearchFilter:    stringPointer("General"),
+		FilterValue:     &name,
+		QueryMembership: &identity.QueryMembershipValues.None,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	users := []identity.Identity{}
+	if identities != nil {
+		for _, ident := range *identities {
+			// groups can't be assigned to work items
+			if ident.IsContainer != nil && *ident.IsContainer {
+				continue
+			}
+			users = append(users, ident)
+		}
+	}
+
+	switch len(users) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrUnknownIdentity, name)
+	case 1:
+		return formatIdentity(&users[0]), nil
+	default:
+		candidates := make([]string, 0, len(users))
+		for i := range users {
+			candidates = append(candidates, formatIdentity(&users[i]))
+		}
+		return "", fmt.Errorf("%w '%s': %s", ErrAmbiguousIdentity, name, strings.Join(candidates, ", "))
+	}
+}
+
+// formatIdentity returns the display name and account of the identity in the
+// format Azure DevOps uses for identity fields.
+func formatIdentity(ident *identity.Identity) string {
+	displayName := ""
+	if ident.ProviderDisplayName != nil {
+		displayName = *ident.ProviderDisplayName
+	}
+	if ident.CustomDisplayName != nil && *ident.CustomDisplayName != "" {
+		displayName = *ident.CustomDisplayName
+	}
+
+	account := identityAccount(ident)
+
+	switch {
+	case account == "":
+		return displayName
+	case displayName == "":
+		return account
+	default:
+		return fmt.Sprintf("%s <%s>", displayName, account)
+	}
+}
+
+// identityAccount returns the account name, usually the email address, from
+// the properties of the identity. Returns an empty string if it isn't set.
+func identityAccount(ident *identity.Identity) string {
+	properties, ok := ident.Properties.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	account, ok := properties["Account"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	value, ok := account["$value"].(string)
+	if !ok {
+		return ""
+	}
+
+	return value
+}
@@ -0,0 +1,139 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/location"
+)
+
+// testIdentity returns an identity with the given display name and account,
+// which are left out if empty.
+func testIdentity(displayName, account string) identity.Identity {
+	ident := identity.Identity{}
+
+	if displayName != "" {
+		ident.ProviderDisplayName = &displayName
+	}
+
+	if account != "" {
+		ident.Properties = map[string]interface{}{
+			"Account": map[string]interface{}{"$type": "System.String", "$value": account},
+		}
+	}
+
+	return ident
+}
+
+// fakeIdentityClient returns all identities whose display name or account
+// contains the filter value.
+type fakeIdentityClient struct {
+	identity.Client
+
+	identities []identity.Identity
+}
+
+func (c *fakeIdentityClient) ReadIdentities(_ context.Context, args identity.ReadIdentitiesArgs) (*[]identity.Identity, error) {
+	found := []identity.Identity{}
+
+	for i := range c.identities {
+		if strings.Contains(formatIdentity(&c.identities[i]), *args.FilterValue) {
+			found = append(found, c.identities[i])
+		}
+	}
+
+	return &found, nil
+}
+
+// fakeLocationClient returns the given user as the authenticated one.
+type fakeLocationClient struct {
+	location.Client
+
+	user *identity.Identity
+}
+
+func (c *fakeLocationClient) GetConnectionData(context.Context, location.GetConnectionDataArgs) (*location.ConnectionData, error) {
+	return &location.ConnectionData{AuthenticatedUser: c.user}, nil
+}
+
+func TestResolveIdentity(t *testing.T) {
+	isContainer := true
+	group := testIdentity("Jane's Team", "")
+	group.IsContainer = &isContainer
+
+	me := testIdentity("Max Mustermann", "max@example.com")
+
+	service := &Service{
+		IdentityClient: &fakeIdentityClient{identities: []identity.Identity{
+			testIdentity("Jane Doe", "jane@example.com"),
+			testIdentity("John Doe", "john@example.com"),
+			group,
+		}},
+		LocationClient: &fakeLocationClient{user: &me},
+	}
+
+	tests := []struct {
+		name        string
+		value       string
+		expected    string
+		expectedErr error
+	}{
+		{name: "me", value: MeIdentity, expected: "Max Mustermann <max@example.com>"},
+		{name: "me ignoring case", value: "@ME", expected: "Max Mustermann <max@example.com>"},
+		{name: "email", value: "jane@example.com", expected: "Jane Doe <jane@example.com>"},
+		{name: "display name", value: "John Doe", expected: "John Doe <john@example.com>"},
+		{name: "groups are skipped", value: "Jane", expected: "Jane Doe <jane@example.com>"},
+		{name: "unknown", value: "Erika", expectedErr: ErrUnknownIdentity},
+		{name: "ambiguous", value: "Doe", expectedErr: ErrAmbiguousIdentity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := service.ResolveIdentity(context.Background(), tt.value)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if resolved != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestResolveIdentityWithoutAuthenticatedUser(t *testing.T) {
+	service := &Service{LocationClient: &fakeLocationClient{}}
+
+	if _, err := service.ResolveIdentity(context.Background(), MeIdentity); !errors.Is(err, ErrUnknownIdentity) {
+		t.Errorf("expected %v, got %v", ErrUnknownIdentity, err)
+	}
+}
+
+func TestFormatIdentity(t *testing.T) {
+	customName := "JD"
+	custom := testIdentity("Jane Doe", "jane@example.com")
+	custom.CustomDisplayName = &customName
+
+	tests := []struct {
+		name     string
+		identity identity.Identity
+		expected string
+	}{
+		{name: "display name and account", identity: testIdentity("Jane Doe", "jane@example.com"), expected: "Jane Doe <jane@example.com>"},
+		{name: "custom display name", identity: custom, expected: "JD <jane@example.com>"},
+		{name: "display name only", identity: testIdentity("Jane Doe", ""), expected: "Jane Doe"},
+		{name: "account only", identity: testIdentity("", "jane@example.com"), expected: "jane@example.com"},
+		{name: "neither", identity: identity.Identity{Properties: "unexpected"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if formatted := formatIdentity(&tt.identity); formatted != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, formatted)
+			}
+		})
+	}
+}
@@ -4,99 +4,307 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/thediveo/klo"
 )
 
 var (
 	ErrIterationPathNotSet = errors.New("iteration path could not be retrieved")
+	ErrNoCurrentIteration  = errors.New("there is no current iteration, use an offset other than 0")
+	ErrIterationNotFound   = errors.New("no iteration found")
+	ErrIterationAmbiguous  = errors.New("more than one iteration found, use the full path instead")
 )
 
-func GetIterationPathFromOffset(ctx context.Context, client work.Client, project string, offset int) (string, error) {
-	iteration, err := getIterationRelativeToCurrent(ctx, client, project, offset)
+// GetIterationPathFromOffset returns the path of the iteration at the given
+// offset relative to the current iteration of the team. An empty team refers
+// to the project's default team.
+func GetIterationPathFromOffset(ctx context.Context, client work.Client, project, team string, offset int) (string, error) {
+	iteration, err := getIterationRelativeToCurrent(ctx, client, project, team, offset)
 	if err != nil {
 		return "", err
 	}
 
-	if iteration.Path == nil || *iteration.Path == "" {
-		return "", ErrIterationPathNotSet
+	return iterationPath(iteration)
+}
+
+// GetIterationPathByName returns the path of the iteration of the team with the
+// given name or path. Names are compared case-insensitively. Returns an error if
+// the name is used by more than one iteration.
+func GetIterationPathByName(ctx context.Context, client work.Client, project, team, name string) (string, error) {
+	all, err := ListIterations(ctx, client, project, team)
+	if err != nil {
+		return "", err
 	}
 
-	return *iteration.Path, nil
+	matches := []string{}
+
+	for i := range all {
+		path, err := iterationPath(&all[i])
+		if err != nil {
+			continue
+		}
+
+		if strings.EqualFold(path, name) {
+			return path, nil
+		}
+
+		if all[i].Name != nil && strings.EqualFold(*all[i].Name, name) {
+			matches = append(matches, path)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrIterationNotFound, name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrIterationAmbiguous, strings.Join(matches, ", "))
+	}
 }
 
-// getCurrentIteration returns a single Iteration object which represents the
-// iteration that is currently in progress in the configured project.
-func getCurrentIteration(ctx context.Context, client work.Client, project string) (*work.TeamSettingsIteration, error) {
+// GetIterationPathByDate returns the path of the iteration of the team whose
+// start and finish dates contain the given day.
+func GetIterationPathByDate(ctx context.Context, client work.Client, project, team string, day time.Time) (string, error) {
+	all, err := ListIterations(ctx, client, project, team)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range all {
+		if iterationContains(&all[i], day) {
+			return iterationPath(&all[i])
+		}
+	}
+
+	return "", fmt.Errorf("%w: for %s", ErrIterationNotFound, day.Format(time.DateOnly))
+}
+
+// ListIterations returns a list of all iterations (past, current and future) of
+// the team in the configured project, sorted by their start dates. Iterations
+// without a start date are put at the end of the list.
+func ListIterations(ctx context.Context, client work.Client, project, team string) ([]work.TeamSettingsIteration, error) {
 	iterations, err := client.GetTeamIterations(ctx, work.GetTeamIterationsArgs{
-		Project:   &project,
-		Timeframe: stringPointer("current"),
+		Project: &project,
+		Team:    optionalStringPointer(team),
 	})
 	if iterations == nil {
+		if err == nil {
+			err = ErrCouldNotGetIterations
+		}
 		return nil, err
 	}
 
-	if len(*iterations) != 1 {
-		return nil, fmt.Errorf("%w: %d iterations found", ErrCurrentIterationUnidentifiable, len(*iterations))
+	sorted := *iterations
+	sort.SliceStable(sorted, func(i, j int) bool {
+		start, otherStart := iterationStart(&sorted[i]), iterationStart(&sorted[j])
+		if start == nil || otherStart == nil {
+			return start != nil
+		}
+		return start.Before(*otherStart)
+	})
+
+	return sorted, nil
+}
+
+// CurrentIterationIndex returns the index of the iteration that is currently in
+// progress within the sorted list of iterations. If no iteration is in
+// progress, it returns the index of the first iteration starting in the future
+// (which might be the length of the list) and false.
+func CurrentIterationIndex(iterations []work.TeamSettingsIteration, now time.Time) (int, bool) {
+	current := []int{}
+
+	for i := range iterations {
+		attributes := iterations[i].Attributes
+		if attributes != nil && attributes.TimeFrame != nil && *attributes.TimeFrame == work.TimeFrameValues.Current {
+			current = append(current, i)
+		}
 	}
 
-	currentIteration := (*iterations)[0]
+	// fall back to the dates if the API didn't tell us
+	if len(current) == 0 {
+		for i := range iterations {
+			if iterationContains(&iterations[i], now) {
+				current = append(current, i)
+			}
+		}
+	}
 
-	return &currentIteration, nil
+	if len(current) > 0 {
+		return current[0], true
+	}
+
+	for i := range iterations {
+		if start := iterationStart(&iterations[i]); start != nil && start.After(now) {
+			return i, false
+		}
+	}
+
+	return len(iterations), false
 }
 
-// listIterations returns a list of all iterations (past, current and future) in
-// the configured project.
-func listIterations(ctx context.Context, client work.Client, project string) (*[]work.TeamSettingsIteration, error) {
-	iterations, err := client.GetTeamIterations(ctx, work.GetTeamIterationsArgs{
-		Project: &project,
-	})
-	if iterations == nil {
+// Iteration summarizes a team iteration, including its offset relative to the
+// current iteration.
+type Iteration struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	StartDate  string `json:"startDate,omitempty"`
+	FinishDate string `json:"finishDate,omitempty"`
+	TimeFrame  string `json:"timeFrame,omitempty"`
+	Offset     int    `json:"offset"`
+	Current    bool   `json:"current"`
+}
+
+var IterationPrinterSpecs = klo.Specs{
+	DefaultColumnSpec: "OFFSET:{.Offset},NAME:{.Name},START:{.StartDate},FINISH:{.FinishDate},CURRENT:{.Current}",
+	WideColumnSpec:    "OFFSET:{.Offset},NAME:{.Name},PATH:{.Path},START:{.StartDate},FINISH:{.FinishDate},TIMEFRAME:{.TimeFrame},CURRENT:{.Current}",
+}
+
+// GetIterations returns all iterations of the team, sorted by their start dates
+// and annotated with their offsets relative to the current iteration.
+func GetIterations(ctx context.Context, client work.Client, project, team string) ([]Iteration, error) {
+	all, err := ListIterations(ctx, client, project, team)
+	if err != nil {
 		return nil, err
 	}
 
+	currentIndex, currentExists := CurrentIterationIndex(all, time.Now())
+	iterations := make([]Iteration, 0, len(all))
+
+	for i := range all {
+		iteration := Iteration{
+			Offset:  IterationOffset(i, currentIndex, currentExists),
+			Current: currentExists && i == currentIndex,
+		}
+
+		if all[i].Name != nil {
+			iteration.Name = *all[i].Name
+		}
+
+		if all[i].Path != nil {
+			iteration.Path = *all[i].Path
+		}
+
+		if start := iterationStart(&all[i]); start != nil {
+			iteration.StartDate = start.Format(time.DateOnly)
+		}
+
+		if finish := iterationFinish(&all[i]); finish != nil {
+			iteration.FinishDate = finish.Format(time.DateOnly)
+		}
+
+		if all[i].Attributes != nil && all[i].Attributes.TimeFrame != nil {
+			iteration.TimeFrame = string(*all[i].Attributes.TimeFrame)
+		}
+
+		iterations = append(iterations, iteration)
+	}
+
 	return iterations, nil
 }
 
+// IterationOffset returns the offset of the iteration at the given index
+// relative to the current iteration, as used by GetIterationPathFromOffset.
+// See CurrentIterationIndex for the meaning of currentIndex and currentExists.
+func IterationOffset(index, currentIndex int, currentExists bool) int {
+	offset := index - currentIndex
+
+	// without a current iteration, currentIndex points to the next iteration,
+	// which has the offset 1
+	if !currentExists && offset >= 0 {
+		offset++
+	}
+
+	return offset
+}
+
 // getIterationRelativeToCurrent takes an integer as offset and will return the
 // iteration relative to the current one, if the iteration at the specified
 // offset does exist. Will return an error if the offset is too far in the past
 // or too far in the future. Using 0 as offset will return the current
 // iteration, using 1 will return the next iteration. Use -1 to get the previous
-// iteration and so on.
-func getIterationRelativeToCurrent(ctx context.Context, client work.Client, project string, offset int) (*work.TeamSettingsIteration, error) {
-	current, err := getCurrentIteration(ctx, client, project)
+// iteration and so on. Iterations are ordered by their start dates. If there is
+// no current iteration, 1 refers to the next upcoming iteration and -1 to the
+// most recent past iteration.
+func getIterationRelativeToCurrent(ctx context.Context, client work.Client, project, team string, offset int) (*work.TeamSettingsIteration, error) {
+	all, err := ListIterations(ctx, client, project, team)
 	if err != nil {
 		return nil, err
 	}
 
-	all, err := listIterations(ctx, client, project)
+	currentIndex, currentExists := CurrentIterationIndex(all, time.Now())
+
+	index, err := iterationIndexAtOffset(len(all), currentIndex, currentExists, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	if current == nil || all == nil {
-		return nil, ErrCouldNotGetIterations
-	}
+	return &all[index], nil
+}
 
-	currentIndex := 0
+// iterationIndexAtOffset is the inverse of IterationOffset. It returns the
+// index of the iteration at the given offset among count iterations, see
+// CurrentIterationIndex for currentIndex and currentExists.
+func iterationIndexAtOffset(count, currentIndex int, currentExists bool, offset int) (int, error) {
+	index := currentIndex + offset
 
-	for i := range *all {
-		if *(*all)[i].Id == *current.Id {
-			currentIndex = i
-			break
+	if !currentExists {
+		if offset == 0 {
+			return 0, fmt.Errorf("%w: %w", ErrNoCurrentIteration, ErrCurrentIterationUnidentifiable)
 		}
+
+		// currentIndex points to the next iteration already
+		if offset > 0 {
+			index--
+		}
+	}
+
+	if count <= index {
+		return 0, fmt.Errorf("%w: %d", ErrOffsetTooFarInFuture, offset)
+	}
+
+	if index < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrOffsetTooFarInPast, offset)
 	}
 
-	relativePos := currentIndex + offset
+	return index, nil
+}
 
-	if len(*all) <= relativePos {
-		return nil, fmt.Errorf("%w: %d", ErrOffsetTooFarInFuture, offset)
+func iterationPath(iteration *work.TeamSettingsIteration) (string, error) {
+	if iteration.Path == nil || *iteration.Path == "" {
+		return "", ErrIterationPathNotSet
 	}
 
-	if relativePos < 0 {
-		return nil, fmt.Errorf("%w: %d", ErrOffsetTooFarInPast, offset)
+	return *iteration.Path, nil
+}
+
+func iterationStart(iteration *work.TeamSettingsIteration) *time.Time {
+	if iteration.Attributes == nil || iteration.Attributes.StartDate == nil {
+		return nil
+	}
+
+	return &iteration.Attributes.StartDate.Time
+}
+
+func iterationFinish(iteration *work.TeamSettingsIteration) *time.Time {
+	if iteration.Attributes == nil || iteration.Attributes.FinishDate == nil {
+		return nil
+	}
+
+	return &iteration.Attributes.FinishDate.Time
+}
+
+// iterationContains returns whether the given point in time lies between the
+// start of the first day and the end of the last day of the iteration.
+func iterationContains(iteration *work.TeamSettingsIteration, t time.Time) bool {
+	start, finish := iterationStart(iteration), iterationFinish(iteration)
+	if start == nil || finish == nil {
+		return false
 	}
 
-	return &(*all)[relativePos], nil
+	return !t.Before(*start) && t.Before(finish.AddDate(0, 0, 1))
 }
@@ -0,0 +1,310 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+)
+
+func testIteration(start, finish string, timeFrame *work.TimeFrame) work.TeamSettingsIteration {
+	attributes := &work.TeamIterationAttributes{TimeFrame: timeFrame}
+
+	if start != "" {
+		t, _ := time.Parse(time.DateOnly, start)
+		attributes.StartDate = &azuredevops.Time{Time: t}
+	}
+
+	if finish != "" {
+		t, _ := time.Parse(time.DateOnly, finish)
+		attributes.FinishDate = &azuredevops.Time{Time: t}
+	}
+
+	return work.TeamSettingsIteration{Attributes: attributes}
+}
+
+func TestCurrentIterationIndex(t *testing.T) {
+	current := work.TimeFrameValues.Current
+	now, _ := time.Parse(time.DateTime, "2024-03-14 12:00:00")
+
+	tests := []struct {
+		name          string
+		iterations    []work.TeamSettingsIteration
+		expectedIndex int
+		expectedFound bool
+	}{
+		{
+			name: "time frame of the API",
+			iterations: []work.TeamSettingsIteration{
+				testIteration("2024-03-01", "2024-03-14", nil),
+				testIteration("2024-03-15", "2024-03-28", &current),
+			},
+			expectedIndex: 1,
+			expectedFound: true,
+		},
+		{
+			name: "dates including the last day",
+			iterations: []work.TeamSettingsIteration{
+				testIteration("2024-02-15", "2024-02-29", nil),
+				testIteration("2024-03-01", "2024-03-14", nil),
+				testIteration("2024-03-15", "2024-03-28", nil),
+			},
+			expectedIndex: 1,
+			expectedFound: true,
+		},
+		{
+			name: "gap between iterations",
+			iterations: []work.TeamSettingsIteration{
+				testIteration("2024-02-01", "2024-02-14", nil),
+				testIteration("2024-03-18", "2024-03-29", nil),
+				testIteration("2024-04-01", "2024-04-12", nil),
+			},
+			expectedIndex: 1,
+			expectedFound: false,
+		},
+		{
+			name: "all iterations in the past",
+			iterations: []work.TeamSettingsIteration{
+				testIteration("2024-01-01", "2024-01-14", nil),
+				testIteration("2024-01-15", "2024-01-28", nil),
+			},
+			expectedIndex: 2,
+			expectedFound: false,
+		},
+		{
+			name: "iteration without dates",
+			iterations: []work.TeamSettingsIteration{
+				testIteration("", "", nil),
+			},
+			expectedIndex: 1,
+			expectedFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, found := CurrentIterationIndex(tt.iterations, now)
+			if index != tt.expectedIndex || found != tt.expectedFound {
+				t.Errorf("expected (%d, %t), got (%d, %t)", tt.expectedIndex, tt.expectedFound, index, found)
+			}
+		})
+	}
+}
+
+func TestIterationOffset(t *testing.T) {
+	tests := []struct {
+		name          string
+		index         int
+		currentIndex  int
+		currentExists bool
+		expected      int
+	}{
+		{name: "current", index: 2, currentIndex: 2, currentExists: true, expected: 0},
+		{name: "next", index: 3, currentIndex: 2, currentExists: true, expected: 1},
+		{name: "previous", index: 0, currentIndex: 2, currentExists: true, expected: -2},
+		{name: "next without current", index: 2, currentIndex: 2, currentExists: false, expected: 1},
+		{name: "later without current", index: 4, currentIndex: 2, currentExists: false, expected: 3},
+		{name: "previous without current", index: 1, currentIndex: 2, currentExists: false, expected: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if offset := IterationOffset(tt.index, tt.currentIndex, tt.currentExists); offset != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, offset)
+			}
+		})
+	}
+}
+
+func TestIterationIndexAtOffset(t *testing.T) {
+	tests := []struct {
+		name          string
+		currentIndex  int
+		currentExists bool
+		offset        int
+		expected      int
+		expectedErr   error
+	}{
+		{name: "current", currentIndex: 2, currentExists: true, offset: 0, expected: 2},
+		{name: "next", currentIndex: 2, currentExists: true, offset: 1, expected: 3},
+		{name: "previous", currentIndex: 2, currentExists: true, offset: -2, expected: 0},
+		{name: "last", currentIndex: 2, currentExists: true, offset: 2, expected: 4},
+		{name: "too far in the future", currentIndex: 2, currentExists: true, offset: 3, expectedErr: ErrOffsetTooFarInFuture},
+		{name: "too far in the past", currentIndex: 2, currentExists: true, offset: -3, expectedErr: ErrOffsetTooFarInPast},
+		{name: "next without current", currentIndex: 2, currentExists: false, offset: 1, expected: 2},
+		{name: "previous without current", currentIndex: 2, currentExists: false, offset: -1, expected: 1},
+		{name: "current without current", currentIndex: 2, currentExists: false, offset: 0, expectedErr: ErrNoCurrentIteration},
+		{name: "current without current for compatibility", currentIndex: 2, currentExists: false, offset: 0, expectedErr: ErrCurrentIterationUnidentifiable},
+		{name: "next after all iterations", currentIndex: 5, currentExists: false, offset: 1, expectedErr: ErrOffsetTooFarInFuture},
+		{name: "previous after all iterations", currentIndex: 5, currentExists: false, offset: -1, expected: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := iterationIndexAtOffset(5, tt.currentIndex, tt.currentExists, tt.offset)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if err == nil && index != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, index)
+			}
+		})
+	}
+}
+
+func TestIterationIndexAtOffsetIsInverseOfIterationOffset(t *testing.T) {
+	for _, currentExists := range []bool{true, false} {
+		for index := 0; index < 5; index++ {
+			offset := IterationOffset(index, 2, currentExists)
+
+			if got, err := iterationIndexAtOffset(5, 2, currentExists, offset); err != nil || got != index {
+				t.Errorf("offset %d (current exists: %t): expected %d, got %d (%v)", offset, currentExists, index, got, err)
+			}
+		}
+	}
+}
+
+// fakeIterationClient returns the given iterations per team, the ones of the
+// empty team being the ones of the project's default team.
+type fakeIterationClient struct {
+	work.Client
+
+	iterations map[string][]work.TeamSettingsIteration
+}
+
+func (c *fakeIterationClient) GetTeamIterations(_ context.Context, args work.GetTeamIterationsArgs) (*[]work.TeamSettingsIteration, error) {
+	team := ""
+	if args.Team != nil {
+		team = *args.Team
+	}
+
+	iterations, exists := c.iterations[team]
+	if !exists {
+		return nil, errors.New("team not found")
+	}
+
+	return &iterations, nil
+}
+
+// teamIteration returns an iteration with the given path, which is the current
+// one if current is set.
+func teamIteration(path, start string, current bool) work.TeamSettingsIteration {
+	timeFrame := &work.TimeFrameValues.Past
+	if current {
+		timeFrame = &work.TimeFrameValues.Current
+	}
+
+	iteration := testIteration(start, "", timeFrame)
+	iteration.Name = &path
+	iteration.Path = &path
+
+	return iteration
+}
+
+func TestIterationsOfTeam(t *testing.T) {
+	client := &fakeIterationClient{iterations: map[string][]work.TeamSettingsIteration{
+		"": {
+			teamIteration("Project\\Sprint 2", "2023-01-15", true),
+			teamIteration("Project\\Sprint 1", "2023-01-01", false),
+		},
+		"Team A": {
+			teamIteration("Project\\Team A\\Week 2", "2023-01-08", true),
+			teamIteration("Project\\Team A\\Week 1", "2023-01-01", false),
+		},
+	}}
+
+	tests := []struct {
+		team           string
+		expectedOffset string
+		expectedByName string
+	}{
+		{team: "", expectedOffset: "Project\\Sprint 1", expectedByName: ""},
+		{team: "Team A", expectedOffset: "Project\\Team A\\Week 1", expectedByName: "Project\\Team A\\Week 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run("team "+tt.team, func(t *testing.T) {
+			path, err := GetIterationPathFromOffset(context.Background(), client, "Project", tt.team, -1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if path != tt.expectedOffset {
+				t.Errorf("expected previous iteration '%s', got '%s'", tt.expectedOffset, path)
+			}
+
+			path, err = GetIterationPathByName(context.Background(), client, "Project", tt.team, "project\\team a\\week 2")
+			if tt.expectedByName == "" {
+				if !errors.Is(err, ErrIterationNotFound) {
+					t.Errorf("expected error %v, got %v", ErrIterationNotFound, err)
+				}
+				return
+			}
+
+			if err != nil || path != tt.expectedByName {
+				t.Errorf("expected iteration '%s', got '%s' and error %v", tt.expectedByName, path, err)
+			}
+		})
+	}
+}
+
+func TestListIterationsOfUnknownTeam(t *testing.T) {
+	client := &fakeIterationClient{}
+
+	if _, err := ListIterations(context.Background(), client, "Project", "Team B"); err == nil {
+		t.Error("expected an error for an unknown team")
+	}
+}
+
+func TestGetIterationPathByDate(t *testing.T) {
+	iteration := func(path, start, finish string) work.TeamSettingsIteration {
+		iteration := testIteration(start, finish, nil)
+		iteration.Path = &path
+		return iteration
+	}
+
+	client := &fakeIterationClient{iterations: map[string][]work.TeamSettingsIteration{
+		"": {
+			iteration("Project\\Sprint 2", "2023-01-16", "2023-01-27"),
+			iteration("Project\\Sprint 1", "2023-01-02", "2023-01-13"),
+			iteration("Project\\Backlog", "", ""),
+		},
+	}}
+
+	tests := []struct {
+		name        string
+		day         string
+		expected    string
+		expectedErr error
+	}{
+		{name: "first day", day: "2023-01-02T00:00:00Z", expected: "Project\\Sprint 1"},
+		{name: "within", day: "2023-01-18T12:00:00Z", expected: "Project\\Sprint 2"},
+		{name: "last day", day: "2023-01-13T00:00:00Z", expected: "Project\\Sprint 1"},
+		{name: "end of last day", day: "2023-01-13T23:59:59Z", expected: "Project\\Sprint 1"},
+		{name: "day after", day: "2023-01-14T00:00:00Z", expectedErr: ErrIterationNotFound},
+		{name: "before all iterations", day: "2022-12-31T00:00:00Z", expectedErr: ErrIterationNotFound},
+		{name: "after all iterations", day: "2023-01-28T00:00:00Z", expectedErr: ErrIterationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, err := time.Parse(time.RFC3339, tt.day)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path, err := GetIterationPathByDate(context.Background(), client, "Project", "", day)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if path != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, path)
+			}
+		})
+	}
+}
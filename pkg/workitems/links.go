@@ -0,0 +1,215 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+const (
+	// WorkItemTypeField is the reference name of the field holding the type of
+	// a work item.
+	WorkItemTypeField = "System.WorkItemType"
+
+	// TitleField is the reference name of the field holding the title of a
+	// work item.
+	TitleField = "System.Title"
+
+	// ParentLinkType links a work item to its parent.
+	ParentLinkType = "System.LinkTypes.Hierarchy-Reverse"
+
+	// TestsLinkType links a test case to the work item it tests, which shows
+	// up as "Tested By" link on that work item.
+	TestsLinkType = "Microsoft.VSTS.Common.TestedBy-Reverse"
+
+	// PredecessorLinkType links a work item to a work item that has to be done
+	// before it.
+	PredecessorLinkType = "System.LinkTypes.Dependency-Reverse"
+)
+
+var (
+	ErrParentNotFound     = errors.New("parent work item doesn't exist")
+	ErrInvalidParentType  = errors.New("parent work item doesn't allow children of this type")
+	ErrUnknownBacklogType = errors.New("work item type isn't part of any backlog of the project")
+
+	ErrCouldNotLinkDependency = errors.New("could not link dependent work items")
+)
+
+// GetParent returns the existing work item with the given ID, after making sure
+// work items of the given type can be created underneath it. Work items can be
+// children of work items that belong to a higher backlog level of the
+// project's process, e.g. a User Story can be a child of a Feature or an Epic,
+// but not of another User Story. Work items that aren't part of any backlog,
+// like Test Cases, can be children of any work item that is.
+func (s *Service) GetParent(ctx context.Context, id int, childType crusado.Type) (*workitemtracking.WorkItem, error) {
+	project := s.ProjectName
+
+	parent, err := s.WorkitemClient.GetWorkItem(ctx, workitemtracking.GetWorkItemArgs{
+		Id:      &id,
+		Project: &project,
+		Fields:  &[]string{WorkItemTypeField, TitleField},
+	})
+	if isNotFound(err) {
+		return nil, fmt.Errorf("%w: %d: %w", ErrParentNotFound, id, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get parent work item %d: %w", id, err)
+	}
+
+	parentType := WorkItemFieldValue(parent, WorkItemTypeField)
+	childWorkItemType := s.WorkItemTypeName(childType)
+
+	levels, err := s.backlogLevels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parentLevel, exists := levels[strings.ToLower(parentType)]
+	if !exists {
+		return nil, fmt.Errorf("%w: %d is a %s", ErrUnknownBacklogType, id, parentType)
+	}
+
+	// work items outside of the backlogs, like test cases, can be children of
+	// any work item on a backlog
+	childLevel, exists := levels[strings.ToLower(childWorkItemType)]
+	if exists && parentLevel <= childLevel {
+		return nil, fmt.Errorf("%w: %s %d cannot be the parent of a %s", ErrInvalidParentType, parentType, id, childWorkItemType)
+	}
+
+	return parent, nil
+}
+
+// isNotFound reports whether the error is a response of Azure DevOps saying the
+// requested resource doesn't exist. The client returns both pointers to and
+// values of wrapped errors.
+func isNotFound(err error) bool {
+	var statusCode *int
+
+	wrapped := &azuredevops.WrappedError{}
+	wrappedValue := azuredevops.WrappedError{}
+
+	switch {
+	case errors.As(err, &wrapped):
+		statusCode = wrapped.StatusCode
+	case errors.As(err, &wrappedValue):
+		statusCode = wrappedValue.StatusCode
+	}
+
+	return statusCode != nil && *statusCode == http.StatusNotFound
+}
+
+// WorkItemFieldValue returns the value of the given field of the work item as a
+// string. Returns an empty string if the field wasn't fetched or isn't set.
+func WorkItemFieldValue(workItem *workitemtracking.WorkItem, field string) string {
+	if workItem == nil || workItem.Fields == nil {
+		return ""
+	}
+
+	value, exists := (*workItem.Fields)[field]
+	if !exists || value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// backlogLevels maps the lower-cased names of all work item types that are part
+// of a backlog to the level of that backlog, starting with 0 for tasks. Bugs
+// are treated as requirements if they aren't part of any backlog on their own.
+func (s *Service) backlogLevels(ctx context.Context) (map[string]int, error) {
+	project := s.ProjectName
+
+	config, err := s.WorkClient.GetProcessConfiguration(ctx, work.GetProcessConfigurationArgs{
+		Project: &project,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get process configuration: %w", err)
+	}
+
+	levels := map[string]int{}
+
+	addCategory := func(category *work.CategoryConfiguration, level int) {
+		if category == nil || category.WorkItemTypes == nil {
+			return
+		}
+
+		for _, workItemType := range *category.WorkItemTypes {
+			if workItemType.Name == nil {
+				continue
+			}
+
+			if _, exists := levels[strings.ToLower(*workItemType.Name)]; !exists {
+				levels[strings.ToLower(*workItemType.Name)] = level
+			}
+		}
+	}
+
+	addCategory(config.TaskBacklog, 0)
+	addCategory(config.RequirementBacklog, 1)
+	addCategory(config.BugWorkItems, 1)
+
+	// portfolio backlogs are ordered from the top, e.g. Epics before Features
+	if config.PortfolioBacklogs != nil {
+		portfolios := *config.PortfolioBacklogs
+		for i := range portfolios {
+			addCategory(&portfolios[i], 1+len(portfolios)-i)
+		}
+	}
+
+	return levels, nil
+}
+
+// LinkDependency links the successor to the predecessor with a dependency link,
+// meaning the predecessor has to be done before the successor. Does nothing in
+// dry-run mode, as the work items don't exist in that case.
+func (s *Service) LinkDependency(ctx context.Context, predecessor, successor *workitemtracking.WorkItem) error {
+	if s.DryRun {
+		return nil
+	}
+
+	if predecessor == nil || successor == nil || successor.Id == nil {
+		return ErrCouldNotLinkDependency
+	}
+
+	project := s.ProjectName
+	document := []webapi.JsonPatchOperation{
+		buildRelationOperation(predecessor, PredecessorLinkType),
+	}
+
+	_, err := s.WorkitemClient.UpdateWorkItem(ctx, workitemtracking.UpdateWorkItemArgs{
+		Document: &document,
+		Id:       successor.Id,
+		Project:  &project,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCouldNotLinkDependency, err)
+	}
+
+	return nil
+}
+
+// buildParentRelationOperation returns the operation that links a new work item
+// to the given parent.
+func buildParentRelationOperation(parent *workitemtracking.WorkItem) webapi.JsonPatchOperation {
+	return buildRelationOperation(parent, ParentLinkType)
+}
+
+// buildRelationOperation returns the operation that links a work item to the
+// given target with the given link type.
+func buildRelationOperation(target *workitemtracking.WorkItem, linkType string) webapi.JsonPatchOperation {
+	return buildJSONPatchOperation(
+		addOp, "/relations/-", workitemtracking.WorkItemRelation{
+			Url: target.Url,
+			Rel: stringPointer(linkType),
+		},
+	)
+}
@@ -0,0 +1,138 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// fakeParentClient returns work items of the given types by their ID, or the
+// given error if it is set.
+type fakeParentClient struct {
+	workitemtracking.Client
+
+	types map[int]string
+	err   error
+}
+
+func (c *fakeParentClient) GetWorkItem(_ context.Context, args workitemtracking.GetWorkItemArgs) (*workitemtracking.WorkItem, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	workItemType, exists := c.types[*args.Id]
+	if !exists {
+		message := "TF401232: Work item does not exist"
+		statusCode := http.StatusNotFound
+		return nil, azuredevops.WrappedError{Message: &message, StatusCode: &statusCode}
+	}
+
+	workItem := testWorkItem(*args.Id)
+	workItem.Fields = &map[string]interface{}{WorkItemTypeField: workItemType}
+
+	return workItem, nil
+}
+
+// fakeWorkClient returns the backlogs of the Agile process.
+type fakeWorkClient struct {
+	work.Client
+}
+
+func (c *fakeWorkClient) GetProcessConfiguration(context.Context, work.GetProcessConfigurationArgs) (*work.ProcessConfiguration, error) {
+	category := func(names ...string) *work.CategoryConfiguration {
+		types := []workitemtracking.WorkItemTypeReference{}
+		for i := range names {
+			types = append(types, workitemtracking.WorkItemTypeReference{Name: &names[i]})
+		}
+
+		return &work.CategoryConfiguration{WorkItemTypes: &types}
+	}
+
+	return &work.ProcessConfiguration{
+		TaskBacklog:        category(TaskType),
+		RequirementBacklog: category(UserStoryType),
+		BugWorkItems:       category(BugType),
+		PortfolioBacklogs:  &[]work.CategoryConfiguration{*category(EpicType), *category(FeatureType)},
+	}, nil
+}
+
+func TestBacklogLevels(t *testing.T) {
+	service := &Service{WorkClient: &fakeWorkClient{}}
+
+	levels, err := service.backlogLevels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]int{"task": 0, "user story": 1, "bug": 1, "feature": 2, "epic": 3}
+	if !reflect.DeepEqual(levels, expected) {
+		t.Errorf("expected %v, got %v", expected, levels)
+	}
+}
+
+func TestGetParent(t *testing.T) {
+	types := map[int]string{1: EpicType, 2: FeatureType, 3: UserStoryType, 4: "Test Plan"}
+
+	tests := []struct {
+		name        string
+		id          int
+		childType   crusado.Type
+		expectedErr error
+	}{
+		{name: "story underneath feature", id: 2, childType: crusado.UserStoryType},
+		{name: "story underneath epic", id: 1, childType: crusado.UserStoryType},
+		{name: "feature underneath epic", id: 1, childType: crusado.FeatureType},
+		{name: "test case underneath story", id: 3, childType: crusado.TestCaseType},
+		{name: "story underneath story", id: 3, childType: crusado.UserStoryType, expectedErr: ErrInvalidParentType},
+		{name: "epic underneath feature", id: 2, childType: crusado.EpicType, expectedErr: ErrInvalidParentType},
+		{name: "bug underneath story", id: 3, childType: crusado.BugType, expectedErr: ErrInvalidParentType},
+		{name: "parent outside of backlogs", id: 4, childType: crusado.UserStoryType, expectedErr: ErrUnknownBacklogType},
+		{name: "missing parent", id: 5, childType: crusado.UserStoryType, expectedErr: ErrParentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{
+				WorkitemClient: &fakeParentClient{types: types},
+				WorkClient:     &fakeWorkClient{},
+			}
+
+			parent, err := service.GetParent(context.Background(), tt.id, tt.childType)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if err == nil && *parent.Id != tt.id {
+				t.Errorf("expected parent %d, got %d", tt.id, *parent.Id)
+			}
+		})
+	}
+}
+
+func TestGetParentPassesOtherErrorsThrough(t *testing.T) {
+	message := "TF400813: The user is not authorized to access this resource"
+	statusCode := http.StatusUnauthorized
+	failure := &azuredevops.WrappedError{Message: &message, StatusCode: &statusCode}
+
+	service := &Service{
+		WorkitemClient: &fakeParentClient{err: failure},
+		WorkClient:     &fakeWorkClient{},
+	}
+
+	_, err := service.GetParent(context.Background(), 1, crusado.UserStoryType)
+	if errors.Is(err, ErrParentNotFound) {
+		t.Errorf("expected the error not to be reported as missing parent, got %v", err)
+	}
+
+	if !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+}
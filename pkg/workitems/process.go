@@ -0,0 +1,314 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/google/uuid"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/core"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtrackingprocess"
+)
+
+const (
+	AgileProcess = "Agile"
+	ScrumProcess = "Scrum"
+	BasicProcess = "Basic"
+	CMMIProcess  = "CMMI"
+
+	// DescriptionField is the reference name of the field most work item
+	// types use for their description.
+	DescriptionField = "System.Description"
+
+	// ReproStepsField is the reference name of the field bugs use instead of a
+	// description.
+	ReproStepsField = "Microsoft.VSTS.TCM.ReproSteps"
+)
+
+var (
+	ErrUnknownProcess        = errors.New("unknown process, should be one of the presets")
+	ErrProcessUndetectable   = errors.New("could not detect the process of the project")
+	ErrInvalidTypeMapping    = errors.New("type mapping is not valid")
+	ErrUnmappedTemplateType  = errors.New("template type isn't mapped to a work item type")
+	errProcessTemplateNotSet = errors.New("project has no process template capability")
+)
+
+// WorkItemType describes the Azure DevOps work item type a template type is
+// created as.
+type WorkItemType struct {
+	// Name is the name of the work item type in Azure DevOps, e.g. Product
+	// Backlog Item
+	Name string
+
+	// DescriptionField is the reference name of the field the description of
+	// the template is written to
+	DescriptionField string
+
+	// SectionFields maps headings of markdown sections to the reference names
+	// of the fields of the work item type they are written to by default
+	SectionFields map[string]string
+}
+
+// TypeMapping maps template types to the work item types they are created as.
+type TypeMapping map[crusado.Type]WorkItemType
+
+// ProcessTypeMappings holds the type mappings of the processes that come with
+// Azure DevOps. Projects using an inherited process use the mapping of the
+// process they inherit from. Only work item types that have the fields of the
+// default sections of their template type get them, see
+// crusado.DefaultSectionFields.
+var ProcessTypeMappings = map[string]TypeMapping{
+	AgileProcess: {
+		crusado.EpicType:       {Name: EpicType, DescriptionField: DescriptionField},
+		crusado.FeatureType:    {Name: FeatureType, DescriptionField: DescriptionField},
+		crusado.UserStoryType:  {Name: UserStoryType, DescriptionField: DescriptionField, SectionFields: crusado.DefaultSectionFields[crusado.UserStoryType]},
+		crusado.BugType:        {Name: BugType, DescriptionField: ReproStepsField, SectionFields: crusado.DefaultSectionFields[crusado.BugType]},
+		crusado.IssueType:      {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.ImpedimentType: {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.TestCaseType:   {Name: TestCaseType, DescriptionField: DescriptionField, SectionFields: crusado.DefaultSectionFields[crusado.TestCaseType]},
+		crusado.TaskType:       {Name: TaskType, DescriptionField: DescriptionField},
+	},
+	// Scrum calls its issues impediments
+	ScrumProcess: {
+		crusado.EpicType:       {Name: EpicType, DescriptionField: DescriptionField},
+		crusado.FeatureType:    {Name: FeatureType, DescriptionField: DescriptionField},
+		crusado.UserStoryType:  {Name: "Product Backlog Item", DescriptionField: DescriptionField, SectionFields: crusado.DefaultSectionFields[crusado.UserStoryType]},
+		crusado.BugType:        {Name: BugType, DescriptionField: ReproStepsField, SectionFields: crusado.DefaultSectionFields[crusado.BugType]},
+		crusado.IssueType:      {Name: ImpedimentType, DescriptionField: DescriptionField},
+		crusado.ImpedimentType: {Name: ImpedimentType, DescriptionField: DescriptionField},
+		crusado.TestCaseType:   {Name: TestCaseType, DescriptionField: DescriptionField, SectionFields: crusado.DefaultSectionFields[crusado.TestCaseType]},
+		crusado.TaskType:       {Name: TaskType, DescriptionField: DescriptionField},
+	},
+	// Basic neither has Features nor Bugs, Issues are used for both stories
+	// and bugs
+	BasicProcess: {
+		crusado.EpicType:       {Name: EpicType, DescriptionField: DescriptionField},
+		crusado.UserStoryType:  {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.BugType:        {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.IssueType:      {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.ImpedimentType: {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.TestCaseType:   {Name: TestCaseType, DescriptionField: DescriptionField, SectionFields: crusado.DefaultSectionFields[crusado.TestCaseType]},
+		crusado.TaskType:       {Name: TaskType, DescriptionField: DescriptionField},
+	},
+	CMMIProcess: {
+		crusado.EpicType:       {Name: EpicType, DescriptionField: DescriptionField},
+		crusado.FeatureType:    {Name: FeatureType, DescriptionField: DescriptionField},
+		crusado.UserStoryType:  {Name: "Requirement", DescriptionField: DescriptionField},
+		crusado.BugType:        {Name: BugType, DescriptionField: ReproStepsField},
+		crusado.IssueType:      {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.ImpedimentType: {Name: IssueType, DescriptionField: DescriptionField},
+		crusado.TestCaseType:   {Name: TestCaseType, DescriptionField: DescriptionField, SectionFields: crusado.DefaultSectionFields[crusado.TestCaseType]},
+		crusado.TaskType:       {Name: TaskType, DescriptionField: DescriptionField},
+	},
+}
+
+// systemProcessIDs maps the IDs of the processes that come with Azure DevOps to
+// their names. These IDs are the same in every organization.
+var systemProcessIDs = map[string]string{
+	"adcc42ab-9882-485e-a3ed-7678f01f66bc": AgileProcess,
+	"6b724908-ef14-45cf-84f8-768b5384da45": ScrumProcess,
+	"b8a3a935-7e91-48b8-a94c-606d37c3e9f2": BasicProcess,
+	"27450541-8e31-4150-9947-dc59f998fc01": CMMIProcess,
+}
+
+// ProcessNames returns the names of all process presets, sorted.
+func ProcessNames() []string {
+	names := make([]string, 0, len(ProcessTypeMappings))
+	for name := range ProcessTypeMappings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// TypeMappingForProcess returns a copy of the type mapping of the process
+// preset with the given name, ignoring case.
+func TypeMappingForProcess(process string) (TypeMapping, error) {
+	for name, mapping := range ProcessTypeMappings {
+		if strings.EqualFold(name, process) {
+			return mapping.With(nil), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: '%s' should be one of %v", ErrUnknownProcess, process, ProcessNames())
+}
+
+// ParseTypeMapping parses a comma-separated list of mappings from template
+// types to work item types, each optionally followed by the reference name of
+// the description field, e.g.
+// "UserStory=Product Backlog Item,Bug=Defect:Custom.Steps". The description
+// field defaults to System.Description.
+func ParseTypeMapping(value string) (TypeMapping, error) {
+	mapping := TypeMapping{}
+	errs := []error{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		templateType, workItemType, found := strings.Cut(entry, "=")
+		if !found || strings.TrimSpace(templateType) == "" || strings.TrimSpace(workItemType) == "" {
+			errs = append(errs, fmt.Errorf("%w: '%s', expected format TemplateType=Work Item Type[:DescriptionField]", ErrInvalidTypeMapping, entry))
+			continue
+		}
+
+		name, descriptionField, _ := strings.Cut(workItemType, ":")
+		if strings.TrimSpace(descriptionField) == "" {
+			descriptionField = DescriptionField
+		}
+
+		mapping[crusado.Type(strings.TrimSpace(templateType))] = WorkItemType{
+			Name:             strings.TrimSpace(name),
+			DescriptionField: strings.TrimSpace(descriptionField),
+		}
+	}
+
+	return mapping, errors.Join(errs...)
+}
+
+// TemplateTypes returns the template types of the mapping, sorted.
+func (m TypeMapping) TemplateTypes() []crusado.Type {
+	types := make([]crusado.Type, 0, len(m))
+	for templateType := range m {
+		types = append(types, templateType)
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	return types
+}
+
+// With returns a copy of the mapping with the given overrides applied.
+// Overrides keep the section fields of the work item type they replace only if
+// they map to a work item type of the same name.
+func (m TypeMapping) With(overrides TypeMapping) TypeMapping {
+	merged := TypeMapping{}
+
+	for templateType, workItemType := range m {
+		merged[templateType] = workItemType
+	}
+
+	for templateType, workItemType := range overrides {
+		if replaced, exists := merged[templateType]; exists && workItemType.SectionFields == nil && workItemType.Name == replaced.Name {
+			workItemType.SectionFields = replaced.SectionFields
+		}
+
+		merged[templateType] = workItemType
+	}
+
+	return merged
+}
+
+// SectionFields returns the section fields of all template types of the
+// mapping, see crusado.Service.SectionFields.
+func (m TypeMapping) SectionFields() map[crusado.Type]map[string]string {
+	sectionFields := map[crusado.Type]map[string]string{}
+
+	for templateType, workItemType := range m {
+		if len(workItemType.SectionFields) > 0 {
+			sectionFields[templateType] = workItemType.SectionFields
+		}
+	}
+
+	return sectionFields
+}
+
+// DetectProcess returns the name of the process preset matching the process
+// of the given project. For projects using an inherited process, the process
+// it inherits from is returned.
+func DetectProcess(ctx context.Context, coreClient core.Client, processClient workitemtrackingprocess.Client, project string) (string, error) {
+	includeCapabilities := true
+
+	teamProject, err := coreClient.GetProject(ctx, core.GetProjectArgs{
+		ProjectId:           &project,
+		IncludeCapabilities: &includeCapabilities,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrProcessUndetectable, err)
+	}
+
+	if teamProject.Capabilities == nil {
+		return "", fmt.Errorf("%w: %w", ErrProcessUndetectable, errProcessTemplateNotSet)
+	}
+
+	processTemplate, exists := (*teamProject.Capabilities)["processTemplate"]
+	if !exists {
+		return "", fmt.Errorf("%w: %w", ErrProcessUndetectable, errProcessTemplateNotSet)
+	}
+
+	processID := strings.ToLower(processTemplate["templateTypeId"])
+	if name, exists := systemProcessIDs[processID]; exists {
+		return name, nil
+	}
+
+	typeID, err := uuid.Parse(processID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrProcessUndetectable, err)
+	}
+
+	process, err := processClient.GetProcessByItsId(ctx, workitemtrackingprocess.GetProcessByItsIdArgs{
+		ProcessTypeId: &typeID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrProcessUndetectable, err)
+	}
+
+	if process.ParentProcessTypeId != nil {
+		if name, exists := systemProcessIDs[strings.ToLower(process.ParentProcessTypeId.String())]; exists {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: process '%s' doesn't inherit from any of %v", ErrProcessUndetectable, processTemplate["templateName"], ProcessNames())
+}
+
+// typeMapping returns the type mapping of the service. Falls back to the Agile
+// process if the service has none.
+func (s *Service) typeMapping() TypeMapping {
+	if s.Types == nil {
+		return ProcessTypeMappings[AgileProcess]
+	}
+
+	return s.Types
+}
+
+// WorkItemType returns the work item type the given template type is created
+// as. Falls back to the Agile process if the service has no type mapping.
+func (s *Service) WorkItemType(templateType crusado.Type) (WorkItemType, error) {
+	workItemType, exists := s.typeMapping()[templateType]
+	if !exists || workItemType.Name == "" {
+		return WorkItemType{}, fmt.Errorf("%w: %s", ErrUnmappedTemplateType, templateType)
+	}
+
+	if workItemType.DescriptionField == "" {
+		workItemType.DescriptionField = DescriptionField
+	}
+
+	return workItemType, nil
+}
+
+// SectionFields returns the section fields of the work item types templates are
+// created as, see crusado.Service.SectionFields.
+func (s *Service) SectionFields() map[crusado.Type]map[string]string {
+	return s.typeMapping().SectionFields()
+}
+
+// WorkItemTypeName returns the name of the work item type the given template
+// type is created as, or the template type itself if it isn't mapped.
+func (s *Service) WorkItemTypeName(templateType crusado.Type) string {
+	workItemType, err := s.WorkItemType(templateType)
+	if err != nil {
+		return string(templateType)
+	}
+
+	return workItemType.Name
+}
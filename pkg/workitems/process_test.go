@@ -0,0 +1,380 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/google/uuid"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/core"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtrackingprocess"
+)
+
+func TestParseTypeMapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    TypeMapping
+		expectedErr error
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: TypeMapping{},
+		},
+		{
+			name:  "default description field",
+			value: "UserStory=Product Backlog Item",
+			expected: TypeMapping{
+				crusado.UserStoryType: {Name: "Product Backlog Item", DescriptionField: DescriptionField},
+			},
+		},
+		{
+			name:  "description field and surrounding whitespace",
+			value: " UserStory = Requirement , Bug=Defect:Custom.Steps ,",
+			expected: TypeMapping{
+				crusado.UserStoryType: {Name: "Requirement", DescriptionField: DescriptionField},
+				crusado.BugType:       {Name: "Defect", DescriptionField: "Custom.Steps"},
+			},
+		},
+		{
+			name:  "empty description field",
+			value: "Bug=Defect:",
+			expected: TypeMapping{
+				crusado.BugType: {Name: "Defect", DescriptionField: DescriptionField},
+			},
+		},
+		{
+			name:  "custom template type",
+			value: "Risk=Risk:Custom.Mitigation",
+			expected: TypeMapping{
+				"Risk": {Name: "Risk", DescriptionField: "Custom.Mitigation"},
+			},
+		},
+		{
+			name:  "later mapping wins",
+			value: "Bug=Defect,Bug=Issue",
+			expected: TypeMapping{
+				crusado.BugType: {Name: "Issue", DescriptionField: DescriptionField},
+			},
+		},
+		{name: "missing equals sign", value: "UserStory", expectedErr: ErrInvalidTypeMapping},
+		{name: "missing template type", value: "=Requirement", expectedErr: ErrInvalidTypeMapping},
+		{name: "missing work item type", value: "UserStory= ", expectedErr: ErrInvalidTypeMapping},
+		{name: "one malformed pair", value: "UserStory=Requirement,Bug", expectedErr: ErrInvalidTypeMapping},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := ParseTypeMapping(tt.value)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if err == nil && !reflect.DeepEqual(mapping, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, mapping)
+			}
+		})
+	}
+}
+
+func TestTypeMappingForProcess(t *testing.T) {
+	tests := []struct {
+		name        string
+		process     string
+		expected    string
+		expectedErr error
+	}{
+		{name: "agile", process: AgileProcess, expected: UserStoryType},
+		{name: "scrum ignoring case", process: "scrum", expected: "Product Backlog Item"},
+		{name: "basic", process: BasicProcess, expected: IssueType},
+		{name: "cmmi", process: CMMIProcess, expected: "Requirement"},
+		{name: "unknown process", process: "Kanban", expectedErr: ErrUnknownProcess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := TypeMappingForProcess(tt.process)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if err == nil && mapping[crusado.UserStoryType].Name != tt.expected {
+				t.Errorf("expected user stories to be created as %s, got %s", tt.expected, mapping[crusado.UserStoryType].Name)
+			}
+		})
+	}
+}
+
+func TestTypeMappingForProcessReturnsCopy(t *testing.T) {
+	mapping, err := TypeMappingForProcess(AgileProcess)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mapping[crusado.UserStoryType] = WorkItemType{Name: "Changed"}
+
+	if name := ProcessTypeMappings[AgileProcess][crusado.UserStoryType].Name; name != UserStoryType {
+		t.Errorf("expected the preset to be unchanged, got %s", name)
+	}
+}
+
+func TestWorkItemType(t *testing.T) {
+	service := &Service{Types: TypeMapping{
+		crusado.UserStoryType: {Name: "Requirement"},
+		crusado.BugType:       {Name: ""},
+	}}
+
+	workItemType, err := service.WorkItemType(crusado.UserStoryType)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := (WorkItemType{Name: "Requirement", DescriptionField: DescriptionField}); !reflect.DeepEqual(workItemType, expected) {
+		t.Errorf("expected %v, got %v", expected, workItemType)
+	}
+
+	for _, templateType := range []crusado.Type{crusado.BugType, crusado.TaskType} {
+		if _, err := service.WorkItemType(templateType); !errors.Is(err, ErrUnmappedTemplateType) {
+			t.Errorf("expected error %v for %s, got %v", ErrUnmappedTemplateType, templateType, err)
+		}
+	}
+
+	if name := service.WorkItemTypeName(crusado.TaskType); name != string(crusado.TaskType) {
+		t.Errorf("expected the template type as name of an unmapped type, got %s", name)
+	}
+}
+
+func TestTypeMappingWithKeepsSectionFieldsOfSameType(t *testing.T) {
+	agile := ProcessTypeMappings[AgileProcess]
+
+	merged := agile.With(TypeMapping{
+		crusado.UserStoryType: {Name: UserStoryType, DescriptionField: "Custom.Description"},
+		crusado.BugType:       {Name: "Defect", DescriptionField: DescriptionField},
+	})
+
+	if fields := merged[crusado.UserStoryType].SectionFields; !reflect.DeepEqual(fields, agile[crusado.UserStoryType].SectionFields) {
+		t.Errorf("expected the section fields of user stories to be kept, got %v", fields)
+	}
+
+	if fields := merged[crusado.BugType].SectionFields; fields != nil {
+		t.Errorf("expected no section fields for a different work item type, got %v", fields)
+	}
+}
+
+// writeSectionsTemplate writes a feature template whose children have sections
+// that are mapped to fields by default, and a user story template that has
+// such a section itself.
+func writeSectionsTemplate(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	content := `---
+name: feature
+type: Feature
+title: Feature
+children:
+- type: Bug
+  title: Bug
+  description: |
+    ## System Info
+    Linux
+- type: UserStory
+  title: Story
+  description: |
+    ## Acceptance Criteria
+    Done
+---
+
+Description
+`
+
+	if err := os.WriteFile(filepath.Join(dir, "feature.md"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	story := "---\nname: story\ntype: UserStory\ntitle: Story\n---\n\n## Acceptance Criteria\nDone\n"
+	if err := os.WriteFile(filepath.Join(dir, "story.md"), []byte(story), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestSectionFieldsOfProcess(t *testing.T) {
+	tests := []struct {
+		process        string
+		expectedFields []map[string]string
+	}{
+		{
+			process: AgileProcess,
+			expectedFields: []map[string]string{
+				{"Microsoft.VSTS.TCM.SystemInfo": "<p>Linux</p>\n"},
+				{"Microsoft.VSTS.Common.AcceptanceCriteria": "<p>Done</p>\n"},
+				{"Microsoft.VSTS.Common.AcceptanceCriteria": "<p>Done</p>\n"},
+			},
+		},
+		{
+			process:        BasicProcess,
+			expectedFields: []map[string]string{{}, {}, {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.process, func(t *testing.T) {
+			types, err := TypeMappingForProcess(tt.process)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			wiService := &Service{Types: types}
+			tplService := &crusado.Service{TemplatesDirectory: writeSectionsTemplate(t), SectionFields: wiService.SectionFields()}
+
+			feature, err := tplService.GetByName("feature")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			story, err := tplService.GetByName("story")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			items := []*Item{}
+			for _, template := range []*crusado.Template{feature, story} {
+				rendered, err := template.Render(nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				tree, err := NewItemTree(rendered)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				items = append(items, tree.Children...)
+				if template == story {
+					items = append(items, tree)
+				}
+			}
+
+			for i, expected := range tt.expectedFields {
+				item := items[i]
+				if !reflect.DeepEqual(item.Fields, expected) {
+					t.Errorf("expected fields %v of %s, got %v", expected, item.Title, item.Fields)
+				}
+
+				// sections that aren't mapped to a field stay in the description
+				if inDescription := strings.Contains(item.Description, "<h2>"); inDescription != (len(expected) == 0) {
+					t.Errorf("unexpected description %q of %s", item.Description, item.Title)
+				}
+			}
+		})
+	}
+}
+
+// fakeCoreClient returns a project with the given process template
+// capability, or none if it is nil.
+type fakeCoreClient struct {
+	core.Client
+
+	processTemplate map[string]string
+}
+
+func (c *fakeCoreClient) GetProject(context.Context, core.GetProjectArgs) (*core.TeamProject, error) {
+	project := &core.TeamProject{}
+
+	if c.processTemplate != nil {
+		project.Capabilities = &map[string]map[string]string{"processTemplate": c.processTemplate}
+	}
+
+	return project, nil
+}
+
+// fakeProcessClient returns processes inheriting from the process with the
+// given parent ID, or fails if it is nil.
+type fakeProcessClient struct {
+	workitemtrackingprocess.Client
+
+	parentID *uuid.UUID
+}
+
+func (c *fakeProcessClient) GetProcessByItsId(_ context.Context, args workitemtrackingprocess.GetProcessByItsIdArgs,
+) (*workitemtrackingprocess.ProcessInfo, error) {
+	if c.parentID == nil {
+		return nil, errors.New("not found")
+	}
+
+	return &workitemtrackingprocess.ProcessInfo{TypeId: args.ProcessTypeId, ParentProcessTypeId: c.parentID}, nil
+}
+
+func TestDetectProcess(t *testing.T) {
+	scrumID := uuid.MustParse("6b724908-ef14-45cf-84f8-768b5384da45")
+	customID := uuid.MustParse("0c5c5c5c-1111-2222-3333-444444444444")
+	inheritedID := "9d4b2a1e-5f6a-4b7c-8d9e-0f1a2b3c4d5e"
+
+	tests := []struct {
+		name            string
+		processTemplate map[string]string
+		parentID        *uuid.UUID
+		expected        string
+		expectedErr     error
+	}{
+		{
+			name:            "system process",
+			processTemplate: map[string]string{"templateTypeId": "adcc42ab-9882-485e-a3ed-7678f01f66bc", "templateName": "Agile"},
+			expected:        AgileProcess,
+		},
+		{
+			name:            "system process ID in upper case",
+			processTemplate: map[string]string{"templateTypeId": "27450541-8E31-4150-9947-DC59F998FC01", "templateName": "CMMI"},
+			expected:        CMMIProcess,
+		},
+		{
+			name:            "inherited process",
+			processTemplate: map[string]string{"templateTypeId": inheritedID, "templateName": "Our Scrum"},
+			parentID:        &scrumID,
+			expected:        ScrumProcess,
+		},
+		{
+			name:            "inherited from unknown process",
+			processTemplate: map[string]string{"templateTypeId": inheritedID, "templateName": "Custom"},
+			parentID:        &customID,
+			expectedErr:     ErrProcessUndetectable,
+		},
+		{
+			name:            "process not found",
+			processTemplate: map[string]string{"templateTypeId": inheritedID, "templateName": "Custom"},
+			expectedErr:     ErrProcessUndetectable,
+		},
+		{
+			name:            "invalid process ID",
+			processTemplate: map[string]string{"templateTypeId": "not-a-uuid"},
+			expectedErr:     ErrProcessUndetectable,
+		},
+		{
+			name:        "no process template",
+			expectedErr: ErrProcessUndetectable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			process, err := DetectProcess(context.Background(), &fakeCoreClient{processTemplate: tt.processTemplate},
+				&fakeProcessClient{parentID: tt.parentID}, "Project")
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if process != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, process)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// RollbackMode determines what happens to the work items that were already
+// created when applying a template fails.
+type RollbackMode string
+
+const (
+	// DeleteRollback moves the created work items to the recycle bin
+	DeleteRollback RollbackMode = "delete"
+
+	// RemoveRollback keeps the created work items, but sets their state to
+	// Removed
+	RemoveRollback RollbackMode = "remove"
+
+	// RemovedState is the state work items are moved to by RemoveRollback
+	RemovedState = "Removed"
+)
+
+// RollbackModes holds all supported rollback modes, DeleteRollback being the
+// default.
+var RollbackModes = []RollbackMode{DeleteRollback, RemoveRollback}
+
+var (
+	ErrInvalidRollbackMode  = errors.New("rollback mode is not valid")
+	ErrCouldNotRollBackItem = errors.New("could not roll back work item")
+)
+
+// ParseRollbackMode returns the rollback mode with the given name, ignoring
+// case. An empty name results in DeleteRollback.
+func ParseRollbackMode(name string) (RollbackMode, error) {
+	if strings.TrimSpace(name) == "" {
+		return DeleteRollback, nil
+	}
+
+	for _, mode := range RollbackModes {
+		if strings.EqualFold(string(mode), strings.TrimSpace(name)) {
+			return mode, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: '%s' should be one of %v", ErrInvalidRollbackMode, name, RollbackModes)
+}
+
+// Rollback undoes the creation of the item and everything underneath it,
+// bottom-up, so children are gone before their parents. Only items that were
+// actually created, see Item.WorkItem, are rolled back, except for the ones
+// restored from a previous run, see Item.Restored. The rolledBack func is
+// called for every item right after it was rolled back, see Walk for the
+// depth. Rolled back items lose their work item, so they are created again by
+// CreateTree. Items that can't be rolled back are skipped, returns their
+// errors. Does nothing in dry-run mode, as nothing was created.
+func (s *Service) Rollback(ctx context.Context, item *Item, mode RollbackMode, rolledBack func(item *Item, depth int)) error {
+	if s.DryRun {
+		return nil
+	}
+
+	type createdItem struct {
+		item  *Item
+		depth int
+	}
+
+	// Walk visits the items in the order they are created
+	created := []createdItem{}
+	item.Walk(func(item *Item, depth int) {
+		if item.WorkItem != nil && item.WorkItem.Id != nil && !item.Restored {
+			created = append(created, createdItem{item: item, depth: depth})
+		}
+	})
+
+	errs := []error{}
+
+	for i := len(created) - 1; i >= 0; i-- {
+		c := created[i]
+
+		if err := s.rollbackWorkItem(ctx, c.item.WorkItem, mode); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s '%s' (#%d): %w", ErrCouldNotRollBackItem, s.WorkItemTypeName(c.item.Type), c.item.Title, *c.item.WorkItem.Id, err))
+			continue
+		}
+
+		if rolledBack != nil {
+			rolledBack(c.item, c.depth)
+		}
+
+		c.item.WorkItem = nil
+		c.item.Linked = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// rollbackWorkItem deletes the work item or sets its state to Removed,
+// depending on the mode. Deleted work items can be restored from the recycle
+// bin.
+func (s *Service) rollbackWorkItem(ctx context.Context, workItem *workitemtracking.WorkItem, mode RollbackMode) error {
+	project := s.ProjectName
+
+	switch mode {
+	case RemoveRollback:
+		document := []webapi.JsonPatchOperation{
+			buildJSONPatchOperation(addOp, "/fields/System.State", RemovedState),
+		}
+
+		_, err := s.WorkitemClient.UpdateWorkItem(ctx, workitemtracking.UpdateWorkItemArgs{
+			Document: &document,
+			Id:       workItem.Id,
+			Project:  &project,
+		})
+
+		return err
+	case DeleteRollback:
+		_, err := s.WorkitemClient.DeleteWorkItem(ctx, workitemtracking.DeleteWorkItemArgs{
+			Id:      workItem.Id,
+			Project: &project,
+		})
+
+		return err
+	default:
+		return fmt.Errorf("%w: '%s' should be one of %v", ErrInvalidRollbackMode, mode, RollbackModes)
+	}
+}
@@ -0,0 +1,160 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// createdTestTree returns the test tree with all items created, the story
+// having ID 1 and the items underneath it the following IDs top-down.
+func createdTestTree() *Item {
+	tree := testTree()
+
+	id := 0
+	tree.Walk(func(item *Item, _ int) {
+		id++
+		item.WorkItem = testWorkItem(id)
+	})
+	tree.Children[1].Linked = []int{0}
+
+	return tree
+}
+
+func TestRollback(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           RollbackMode
+		expectedDelete []int
+		expectedUpdate []int
+	}{
+		{name: "delete", mode: DeleteRollback, expectedDelete: []int{5, 4, 3, 2, 1}},
+		{name: "remove", mode: RemoveRollback, expectedUpdate: []int{5, 4, 3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{}
+			service := &Service{WorkitemClient: client}
+			tree := createdTestTree()
+
+			rolledBack := []string{}
+			err := service.Rollback(context.Background(), tree, tt.mode, func(item *Item, depth int) {
+				rolledBack = append(rolledBack, fmt.Sprintf("%d %s", depth, item.Title))
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(client.deleted, tt.expectedDelete) || !reflect.DeepEqual(client.updated, tt.expectedUpdate) {
+				t.Errorf("expected deletes %v and updates %v, got %v and %v", tt.expectedDelete, tt.expectedUpdate, client.deleted, client.updated)
+			}
+
+			for _, id := range tt.expectedUpdate {
+				if client.states[id] != RemovedState {
+					t.Errorf("expected #%d to be moved to state %s, got '%s'", id, RemovedState, client.states[id])
+				}
+			}
+
+			// children are rolled back before their parents
+			expected := []string{"2 Automate", "1 Test", "1 Deploy", "1 Build", "0 Story"}
+			if !reflect.DeepEqual(rolledBack, expected) {
+				t.Errorf("expected %v to be rolled back, got %v", expected, rolledBack)
+			}
+
+			tree.Walk(func(item *Item, _ int) {
+				if item.WorkItem != nil || item.Linked != nil {
+					t.Errorf("expected %s to lose its work item and links", item.Title)
+				}
+			})
+		})
+	}
+}
+
+func TestRollbackSkipsRestoredItems(t *testing.T) {
+	client := &fakeClient{}
+	service := &Service{WorkitemClient: client}
+
+	// the story and the first task were created by a previous run
+	tree := createdTestTree()
+	tree.Restored = true
+	tree.Children[0].Restored = true
+
+	if err := service.Rollback(context.Background(), tree, DeleteRollback, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []int{5, 4, 3}; !reflect.DeepEqual(client.deleted, expected) {
+		t.Errorf("expected %v to be deleted, got %v", expected, client.deleted)
+	}
+
+	if tree.WorkItem == nil || tree.Children[0].WorkItem == nil {
+		t.Errorf("expected the restored items to keep their work items")
+	}
+}
+
+func TestRollbackContinuesAfterFailure(t *testing.T) {
+	client := &fakeClient{failing: map[int]bool{2: true, 4: true}}
+	service := &Service{WorkitemClient: client}
+	tree := createdTestTree()
+
+	err := service.Rollback(context.Background(), tree, DeleteRollback, nil)
+	if !errors.Is(err, ErrCouldNotRollBackItem) {
+		t.Fatalf("expected error %v, got %v", ErrCouldNotRollBackItem, err)
+	}
+
+	if expected := []int{5, 3, 1}; !reflect.DeepEqual(client.deleted, expected) {
+		t.Errorf("expected %v to be deleted, got %v", expected, client.deleted)
+	}
+
+	remaining := []int{}
+	tree.Walk(func(item *Item, _ int) {
+		if item.WorkItem != nil {
+			remaining = append(remaining, *item.WorkItem.Id)
+		}
+	})
+	if expected := []int{2, 4}; !reflect.DeepEqual(remaining, expected) {
+		t.Errorf("expected %v to keep their work items, got %v", expected, remaining)
+	}
+}
+
+func TestRollbackInDryRun(t *testing.T) {
+	client := &fakeClient{}
+	service := &Service{WorkitemClient: client, DryRun: true}
+
+	if err := service.Rollback(context.Background(), createdTestTree(), DeleteRollback, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(client.deleted) != 0 || len(client.updated) != 0 {
+		t.Errorf("expected nothing to be rolled back, got deletes %v and updates %v", client.deleted, client.updated)
+	}
+}
+
+func TestParseRollbackMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    RollbackMode
+		expectedErr error
+	}{
+		{name: "", expected: DeleteRollback},
+		{name: "delete", expected: DeleteRollback},
+		{name: " Remove ", expected: RemoveRollback},
+		{name: "archive", expectedErr: ErrInvalidRollbackMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := ParseRollbackMode(tt.name)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if mode != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, mode)
+			}
+		})
+	}
+}
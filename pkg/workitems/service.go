@@ -3,26 +3,42 @@ package workitems
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 
 	"github.com/simonkienzler/crusado/pkg/crusado"
 
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/location"
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
 )
 
 const (
-	UserStoryType = "User Story"
-	BugType       = "Bug"
-	TaskType      = "Task"
+	EpicType       = "Epic"
+	FeatureType    = "Feature"
+	UserStoryType  = "User Story"
+	BugType        = "Bug"
+	IssueType      = "Issue"
+	ImpedimentType = "Impediment"
+	TestCaseType   = "Test Case"
+	TaskType       = "Task"
 )
 
 var (
+	// ErrCurrentIterationUnidentifiable is returned together with
+	// ErrNoCurrentIteration if the current iteration can't be determined.
+	//
+	// Deprecated: Use ErrNoCurrentIteration instead.
 	ErrCurrentIterationUnidentifiable = errors.New("search for current iteration returned unexpected number of results")
-	ErrCouldNotGetIterations          = errors.New("could not properly get the current or all iterations")
-	ErrOffsetTooFarInFuture           = errors.New("offset points to a non-existent iteration in the future")
-	ErrOffsetTooFarInPast             = errors.New("offset points to a non-existent iteration in the past")
-	ErrTaskWithoutParent              = errors.New("cannot create task underneath work item without parent")
-	ErrCouldNotAssertLinks            = errors.New("could not assert the expected type from the workItems' Links field")
+
+	ErrCouldNotGetIterations = errors.New("could not properly get the current or all iterations")
+	ErrOffsetTooFarInFuture  = errors.New("offset points to a non-existent iteration in the future")
+	ErrOffsetTooFarInPast    = errors.New("offset points to a non-existent iteration in the past")
+	ErrTaskWithoutParent     = errors.New("cannot create task underneath work item without parent")
+	ErrCouldNotAssertLinks   = errors.New("could not assert the expected type from the workItems' Links field")
 )
 
 // addOp is a shortcut variable for the Add operation.
@@ -32,58 +48,113 @@ var addOp = webapi.OperationValues.Add
 // for creating user stories, bugs or tasks.
 type Service struct {
 	WorkitemClient workitemtracking.Client
+	WorkClient     work.Client
+	IdentityClient identity.Client
+	LocationClient location.Client
 	DryRun         bool
 
 	ProjectName   string
+	TeamName      string
 	AreaPath      string
 	IterationPath string
+
+	// Tags are added to every work item created by the service
+	Tags []string
+
+	// Types maps template types to the work item types they are created as.
+	// Defaults to the types of the Agile process
+	Types TypeMapping
+
+	// Batch makes CreateTree send the work items in as few batch requests as
+	// possible instead of one request per work item. Requires Connection
+	Batch      bool
+	Connection *azuredevops.Connection
+
+	// batchSender sends batch requests instead of Connection if set, see
+	// sendBatch
+	batchSender func(ctx context.Context, requests []batchRequest) (*batchResponse, error)
 }
 
 // Create is responsible for creating arbitrary workitems of the specified type.
-func (s *Service) Create(ctx context.Context, title, description string, templateType crusado.Type) (*workitemtracking.WorkItem, error) {
+// The fields map additional field reference names to their values. The tags
+// are added to the ones configured for the service. If parent is given, the
+// work item is created as its child, see GetParent.
+func (s *Service) Create(ctx context.Context, title, description string, templateType crusado.Type, fields map[string]string,
+	tags []string, parent *workitemtracking.WorkItem,
+) (*workitemtracking.WorkItem, error) {
+	return s.createLinked(ctx, title, description, templateType, fields, tags, parent, ParentLinkType)
+}
+
+// createLinked creates a work item like Create does, but links it to the given
+// work item with the given link type.
+func (s *Service) createLinked(ctx context.Context, title, description string, templateType crusado.Type, fields map[string]string,
+	tags []string, target *workitemtracking.WorkItem, linkType string,
+) (*workitemtracking.WorkItem, error) {
 	project := s.ProjectName
-	workItemType := getWorkItemTypeForTemplateType(templateType)
 	validateOnly := s.DryRun
-	document := s.buildBasicWorkItemJSONPatchDocument(title, description, templateType)
+
+	document, workItemType, err := s.buildWorkItemJSONPatchDocument(title, description, templateType, fields, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// the target already exists, so linking to it works in dry-run mode, too
+	if target != nil {
+		document = append(document, buildRelationOperation(target, linkType))
+	}
 
 	return s.WorkitemClient.CreateWorkItem(ctx, workitemtracking.CreateWorkItemArgs{
 		Document:     &document,
 		Project:      &project,
-		Type:         &workItemType,
+		Type:         &workItemType.Name,
 		ValidateOnly: &validateOnly,
 	})
 }
 
-func (s *Service) CreateTaskUnderneath(ctx context.Context, title, description string, parent *workitemtracking.WorkItem) (*workitemtracking.WorkItem, error) {
+// CreateTaskUnderneath creates a task as child of the given parent. The fields
+// map additional field reference names to their values. The tags are added to
+// the ones configured for the service.
+func (s *Service) CreateTaskUnderneath(ctx context.Context, title, description string, fields map[string]string, tags []string,
+	parent *workitemtracking.WorkItem,
+) (*workitemtracking.WorkItem, error) {
 	project := s.ProjectName
-	workItemType := TaskType
 	validateOnly := s.DryRun
-	document := s.buildBasicWorkItemJSONPatchDocument(title, description, crusado.TaskType)
 
 	if parent == nil {
 		return nil, ErrTaskWithoutParent
 	}
 
+	document, workItemType, err := s.buildWorkItemJSONPatchDocument(title, description, crusado.TaskType, fields, tags)
+	if err != nil {
+		return nil, err
+	}
+
 	// if we're in dry-run mode, don't specify the parent-child relationship,
 	// because this would trigger an existence check on the parent. This fails
 	// and the command would error.
 	if !validateOnly {
-		document = append(document, buildJSONPatchOperation(
-			addOp, "/relations/-", workitemtracking.WorkItemRelation{
-				Url: parent.Url,
-				Rel: stringPointer("System.LinkTypes.Hierarchy-Reverse"),
-			},
-		))
+		document = append(document, buildParentRelationOperation(parent))
 	}
 
 	return s.WorkitemClient.CreateWorkItem(ctx, workitemtracking.CreateWorkItemArgs{
 		Document:     &document,
 		Project:      &project,
-		Type:         &workItemType,
+		Type:         &workItemType.Name,
 		ValidateOnly: &validateOnly,
 	})
 }
 
+// GetWorkItem returns the work item with the given ID.
+func (s *Service) GetWorkItem(ctx context.Context, id int) (*workitemtracking.WorkItem, error) {
+	project := s.ProjectName
+
+	return s.WorkitemClient.GetWorkItem(ctx, workitemtracking.GetWorkItemArgs{
+		Id:      &id,
+		Project: &project,
+		Expand:  &workitemtracking.WorkItemExpandValues.Links,
+	})
+}
+
 // GetWorkItemHTMLRef returns the URL pointing to the Azure DevOps link that
 // shows the HTML view of the passed work item. That's the URL the user would
 // want to visit in a browser. Returns an error if the necessary type assertion
@@ -112,25 +183,77 @@ func (s *Service) GetWorkItemHTMLRef(workItem *workitemtracking.WorkItem) (*stri
 	return &href, nil
 }
 
-func (s *Service) buildBasicWorkItemJSONPatchDocument(title, description string, templateType crusado.Type) []webapi.JsonPatchOperation {
-	fieldPathForDescription := ""
-	switch templateType {
-	// Bug doesn't use a description, but rather Repro Steps
-	case crusado.BugType:
-		fieldPathForDescription = "/fields/Microsoft.VSTS.TCM.ReproSteps"
-	case crusado.UserStoryType:
-		fieldPathForDescription = "/fields/System.Description"
-	case crusado.TaskType:
-		fieldPathForDescription = "/fields/System.Description"
-	default:
-		fieldPathForDescription = "/fields/System.Description"
-	}
-	return []webapi.JsonPatchOperation{
+// buildWorkItemJSONPatchDocument returns the operations setting all fields of a
+// new work item of the given template type, together with the work item type
+// it is created as.
+func (s *Service) buildWorkItemJSONPatchDocument(title, description string, templateType crusado.Type, fields map[string]string,
+	tags []string,
+) ([]webapi.JsonPatchOperation, WorkItemType, error) {
+	workItemType, err := s.WorkItemType(templateType)
+	if err != nil {
+		return nil, WorkItemType{}, err
+	}
+
+	document := s.buildBasicWorkItemJSONPatchDocument(title, description, workItemType.DescriptionField, tags)
+	document = append(document, buildFieldJSONPatchOperations(fields)...)
+
+	return document, workItemType, nil
+}
+
+// buildBasicWorkItemJSONPatchDocument returns the operations setting title,
+// description, area path, iteration path and tags. The description is written
+// to the given field, as not all work item types use System.Description, e.g.
+// bugs use their repro steps instead.
+func (s *Service) buildBasicWorkItemJSONPatchDocument(title, description, descriptionField string, tags []string) []webapi.JsonPatchOperation {
+	document := []webapi.JsonPatchOperation{
 		buildJSONPatchOperation(addOp, "/fields/System.Title", title),
-		buildJSONPatchOperation(addOp, fieldPathForDescription, description),
+		buildJSONPatchOperation(addOp, "/fields/"+descriptionField, description),
 		buildJSONPatchOperation(addOp, "/fields/System.AreaPath", s.AreaPath),
 		buildJSONPatchOperation(addOp, "/fields/System.IterationPath", s.IterationPath),
 	}
+
+	if allTags := JoinTags(append(append([]string{}, tags...), s.Tags...)); allTags != "" {
+		document = append(document, buildJSONPatchOperation(addOp, "/fields/System.Tags", allTags))
+	}
+
+	return document
+}
+
+// JoinTags joins the given tags in the format Azure DevOps expects for the
+// System.Tags field. Tags are trimmed and de-duplicated, ignoring case, as
+// Azure DevOps treats tags case-insensitively. Empty tags are dropped.
+func JoinTags(tags []string) string {
+	seen := map[string]bool{}
+	unique := []string{}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+
+		seen[strings.ToLower(tag)] = true
+		unique = append(unique, tag)
+	}
+
+	return strings.Join(unique, "; ")
+}
+
+// buildFieldJSONPatchOperations returns an add operation for each of the given
+// fields, sorted by field reference name.
+func buildFieldJSONPatchOperations(fields map[string]string) []webapi.JsonPatchOperation {
+	refs := make([]string, 0, len(fields))
+	for ref := range fields {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	operations := make([]webapi.JsonPatchOperation, 0, len(refs))
+	for _, ref := range refs {
+		operations = append(operations, buildJSONPatchOperation(addOp, "/fields/"+ref, fields[ref]))
+	}
+
+	return operations
 }
 
 func buildJSONPatchOperation(op webapi.Operation, path string, value interface{}) webapi.JsonPatchOperation {
@@ -145,15 +268,12 @@ func stringPointer(s string) *string {
 	return &s
 }
 
-func getWorkItemTypeForTemplateType(templateType crusado.Type) string {
-	switch templateType {
-	case crusado.UserStoryType:
-		return UserStoryType
-	case crusado.BugType:
-		return BugType
-	case crusado.TaskType:
-		return TaskType
-	default:
-		return ""
+// optionalStringPointer returns nil for empty strings, so optional API
+// arguments are left out.
+func optionalStringPointer(s string) *string {
+	if s == "" {
+		return nil
 	}
+
+	return &s
 }
@@ -0,0 +1,62 @@
+package workitems
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoinTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		expected string
+	}{
+		{name: "no tags", tags: nil, expected: ""},
+		{name: "single tag", tags: []string{"backend"}, expected: "backend"},
+		{name: "multiple tags", tags: []string{"backend", "crusado"}, expected: "backend; crusado"},
+		{name: "trimmed", tags: []string{" backend ", "\tcrusado"}, expected: "backend; crusado"},
+		{name: "empty tags dropped", tags: []string{"", "backend", "  "}, expected: "backend"},
+		{name: "duplicates ignoring case", tags: []string{"Backend", "crusado", "backend", " BACKEND"}, expected: "Backend; crusado"},
+		{name: "only empty tags", tags: []string{"", " "}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if joined := JoinTags(tt.tags); joined != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, joined)
+			}
+		})
+	}
+}
+
+func TestBuildFieldJSONPatchOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		expected []string
+	}{
+		{name: "no fields", fields: nil, expected: []string{}},
+		{
+			name:     "sorted by reference name",
+			fields:   map[string]string{"System.Tags": "backend", "Custom.Team": "Backend", "Microsoft.VSTS.Common.Priority": "1"},
+			expected: []string{"/fields/Custom.Team=Backend", "/fields/Microsoft.VSTS.Common.Priority=1", "/fields/System.Tags=backend"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operations := []string{}
+			for _, operation := range buildFieldJSONPatchOperations(tt.fields) {
+				if *operation.Op != addOp {
+					t.Errorf("expected operation %s, got %s", addOp, *operation.Op)
+				}
+
+				operations = append(operations, *operation.Path+"="+operation.Value.(string))
+			}
+
+			if !reflect.DeepEqual(operations, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, operations)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package workitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/simonkienzler/crusado/pkg/crusado"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// Item is a work item to create, together with the work items to create
+// underneath it.
+type Item struct {
+	Type        crusado.Type
+	Title       string
+	Description string
+
+	// Fields maps field reference names to their values, see
+	// crusado.Template.WorkItemFields
+	Fields map[string]string
+	Tags   []string
+
+	// LinkType is the type of the link from the item to its parent. Defaults
+	// to ParentLinkType
+	LinkType string
+
+	// Children are created underneath the item, tasks first
+	Children []*Item
+
+	// DependsOn holds the indexes of the item's siblings that have to be done
+	// before the item
+	DependsOn []int
+
+	// Linked holds the indexes of the predecessors the item has already been
+	// linked to
+	Linked []int
+
+	// WorkItem is set once the item has been created. Items that already have
+	// a work item, e.g. from a previous run, aren't created again
+	WorkItem *workitemtracking.WorkItem
+
+	// Restored is set for items whose work item was created by a previous
+	// run. Their work items are never rolled back, see Rollback
+	Restored bool
+}
+
+// NewItemTree returns the tree of items to create for the given rendered
+// template. Returns an error if the dependencies between tasks can't be
+// resolved.
+func NewItemTree(template *crusado.Template) (*Item, error) {
+	root := &Item{
+		Type:        template.Type,
+		Title:       template.Title,
+		Description: template.Description,
+		Fields:      template.WorkItemFields(),
+		Tags:        template.Tags,
+	}
+
+	dependencies, err := template.TaskDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	root.Children = newTaskItems(template.Tasks, dependencies)
+
+	for i := range template.Children {
+		child, err := newChildItem(&template.Children[i])
+		if err != nil {
+			return nil, err
+		}
+
+		root.Children = append(root.Children, child)
+	}
+
+	return root, nil
+}
+
+func newChildItem(child *crusado.Child) (*Item, error) {
+	item := &Item{
+		Type:        child.Type,
+		Title:       child.Title,
+		Description: child.Description,
+		Fields:      child.WorkItemFields(),
+		Tags:        child.Tags,
+	}
+
+	if child.Link == crusado.TestedByLink {
+		item.LinkType = TestsLinkType
+	}
+
+	dependencies, err := child.TaskDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	item.Children = newTaskItems(child.Tasks, dependencies)
+
+	for i := range child.Children {
+		grandchild, err := newChildItem(&child.Children[i])
+		if err != nil {
+			return nil, err
+		}
+
+		item.Children = append(item.Children, grandchild)
+	}
+
+	return item, nil
+}
+
+// newTaskItems returns an item for each task. As tasks are the first children
+// of their parent, the indexes of the dependencies stay valid.
+func newTaskItems(tasks []crusado.Task, dependencies [][]int) []*Item {
+	items := make([]*Item, len(tasks))
+
+	for i := range tasks {
+		items[i] = &Item{
+			Type:        crusado.TaskType,
+			Title:       tasks[i].Title,
+			Description: tasks[i].Description,
+			Fields:      tasks[i].WorkItemFields(),
+			Tags:        tasks[i].Tags,
+			DependsOn:   dependencies[i],
+		}
+	}
+
+	return items
+}
+
+// Walk calls fn for the item and everything underneath it, top-down, with the
+// depth of each item in the tree, starting at 0 for the item itself.
+func (item *Item) Walk(fn func(item *Item, depth int)) {
+	item.walk(fn, 0)
+}
+
+func (item *Item) walk(fn func(item *Item, depth int), depth int) {
+	fn(item, depth)
+
+	for _, child := range item.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// CreateTree creates the item and everything underneath it, top-down, and links
+// each work item to its parent, see Item.LinkType. The item itself is created
+// underneath the given parent, which may be nil. Once all children of an item
+// exist, the dependencies between them are linked. Items that already have a
+// work item and dependencies that are already linked are skipped, so a tree
+// can be created in several runs. The created func is called for every item
+// right after it was created, see Walk for the depth.
+//
+// In batch mode, see Service.Batch, the items are created with as few batch
+// requests as possible. If the batch endpoint isn't supported, the items that
+// haven't been created yet are created one by one. Other failures of batch
+// requests are returned, as creating the items again might duplicate them.
+// Dry runs are never batched, as batch requests can't be validated only.
+func (s *Service) CreateTree(ctx context.Context, item *Item, parent *workitemtracking.WorkItem, created func(item *Item, depth int)) error {
+	if s.Batch && !s.DryRun {
+		err := s.createTreeBatched(ctx, item, parent, created)
+		if !errors.Is(err, ErrBatchUnavailable) {
+			return err
+		}
+	}
+
+	return s.createTree(ctx, item, parent, created, 0)
+}
+
+func (s *Service) createTree(ctx context.Context, item *Item, parent *workitemtracking.WorkItem, created func(item *Item, depth int), depth int) error {
+	var err error
+
+	linkType := item.LinkType
+	if linkType == "" {
+		linkType = ParentLinkType
+	}
+
+	if item.WorkItem == nil {
+		var workItem *workitemtracking.WorkItem
+
+		if item.Type == crusado.TaskType {
+			workItem, err = s.CreateTaskUnderneath(ctx, item.Title, item.Description, item.Fields, item.Tags, parent)
+		} else {
+			workItem, err = s.createLinked(ctx, item.Title, item.Description, item.Type, item.Fields, item.Tags, parent, linkType)
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not create %s '%s': %w", s.WorkItemTypeName(item.Type), item.Title, err)
+		}
+
+		item.WorkItem = workItem
+
+		if created != nil {
+			created(item, depth)
+		}
+	}
+
+	// in dry-run mode, the work item doesn't exist, so its children can't be
+	// linked to it. Tasks take care of that themselves
+	childParent := item.WorkItem
+	if s.DryRun {
+		childParent = nil
+	}
+
+	for _, child := range item.Children {
+		if child.Type == crusado.TaskType {
+			err = s.createTree(ctx, child, item.WorkItem, created, depth+1)
+		} else {
+			err = s.createTree(ctx, child, childParent, created, depth+1)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, successor := range item.Children {
+		for _, predecessor := range successor.DependsOn {
+			if successor.isLinkedTo(predecessor) {
+				continue
+			}
+
+			if err := s.LinkDependency(ctx, item.Children[predecessor].WorkItem, successor.WorkItem); err != nil {
+				return err
+			}
+
+			successor.Linked = append(successor.Linked, predecessor)
+		}
+	}
+
+	return nil
+}
+
+func (item *Item) isLinkedTo(predecessor int) bool {
+	for _, linked := range item.Linked {
+		if linked == predecessor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckTypes makes sure the types of the item and of everything underneath it
+// are mapped to work item types, so creating the tree doesn't fail halfway
+// through. Returns an error listing all unmapped types.
+func (s *Service) CheckTypes(item *Item) error {
+	checked := map[crusado.Type]bool{}
+	errs := []error{}
+
+	item.Walk(func(item *Item, _ int) {
+		if checked[item.Type] {
+			return
+		}
+		checked[item.Type] = true
+
+		if _, err := s.WorkItemType(item.Type); err != nil {
+			errs = append(errs, err)
+		}
+	})
+
+	return errors.Join(errs...)
+}